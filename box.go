@@ -2,27 +2,44 @@ package goxr
 
 import (
 	"errors"
+	"fmt"
 	"github.com/echocat/goxr/box/fs"
 	"github.com/echocat/goxr/box/packed"
 	"github.com/echocat/goxr/common"
 	"github.com/echocat/goxr/runtime"
 	"github.com/echocat/slf4g"
 	"io"
+	"os"
 	"path/filepath"
-	sr "runtime"
+	"reflect"
 	"strings"
 )
 
 type OnFallbackToFsBoxFunc func(packedBoxCandidateFilename string, bases []string, fsBox Box) error
 
+// OnOverlayFunc is called if OverlayEnv is set before the overlays are applied to
+// the given box. If apply is false the overlays are ignored.
+type OnOverlayFunc func(box Box, overlays []string) (apply bool, err error)
+
+// OverlayEnv contains a list of box files or base directories (separated by
+// os.PathListSeparator) which are layered on top of the box opened by OpenBox.
+// Earlier entries have higher priority. If AllowFallbackToFsBox is false the
+// overlays are ignored by OnOverlay_Default and a warning is logged.
+const OverlayEnv = "GOXR_BOX_OVERLAY"
+
 var (
 	// AllowFallbackToFsBox could easily set while build time using:
 	// go build -ldflags="-X github.com/echocat/goxr.AllowFallbackToFsBox=false" .
 	// This is useful in case for behave differently for build versions of you application
 	AllowFallbackToFsBox                       = true
 	OnFallbackToFsBox    OnFallbackToFsBoxFunc = OnFallbackToFsBox_Default
+	OnOverlay            OnOverlayFunc         = OnOverlay_Default
+
+	packageName = reflect.TypeOf(CombinedBox{}).PkgPath()
 
 	ErrBoxIterationNotSupported = errors.New("box iteration not supported")
+	ErrBoxWatchNotSupported     = errors.New("box watch not supported")
+	ErrNoTemplatesFound         = errors.New("no templates found")
 )
 
 type Box interface {
@@ -35,6 +52,12 @@ type Iterable interface {
 	ForEach(common.FilePredicate, func(common.FileInfo) error) error
 }
 
+// Watchable is implemented by boxes which are able to report changes of their
+// files. The listener is called until the returned io.Closer is closed.
+type Watchable interface {
+	Watch(listener common.ChangeListener) (io.Closer, error)
+}
+
 func OpenBox(base ...string) (Box, error) {
 	if executable, err := runtime.Executable(); err != nil {
 		return nil, err
@@ -52,16 +75,41 @@ func OpenPackedBox() (Box, error) {
 }
 
 func OpenBoxBy(packedBoxCandidateFilename string, base ...string) (Box, error) {
+	if box, err := openBoxBy(packedBoxCandidateFilename, base...); err != nil {
+		return nil, err
+	} else {
+		return applyOverlays(box)
+	}
+}
+
+// OpenPackedOrFsBox opens the given filename as packed box. If it does not
+// contain a box it is opened as base directory of a fs box.
+func OpenPackedOrFsBox(filename string) (Box, error) {
+	if box, err := packed.OpenBox(filename); common.IsDoesNotContainBox(err) {
+		return fs.OpenBox(filename)
+	} else if err != nil {
+		return nil, err
+	} else {
+		return box, nil
+	}
+}
+
+func openBoxBy(packedBoxCandidateFilename string, base ...string) (Box, error) {
 	if packedBox, err := packed.OpenBox(packedBoxCandidateFilename); common.IsDoesNotContainBox(err) {
-		if box, err := openFsBox(base); err != nil {
-			return nil, err
-		} else if OnFallbackToFsBox == nil {
-			return box, nil
-		} else if err := OnFallbackToFsBox(packedBoxCandidateFilename, base, box); err != nil {
+		box, unresolved, err := openFsBox(base)
+		if err != nil {
 			return nil, err
-		} else {
-			return box, nil
 		}
+		if OnFallbackToFsBox != nil {
+			if err := OnFallbackToFsBox(packedBoxCandidateFilename, base, box); err != nil {
+				return nil, err
+			}
+		}
+		for _, err := range unresolved {
+			log.WithError(err).
+				Warn("Cannot find base of box; it is resolved against its first candidate.")
+		}
+		return box, nil
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -69,39 +117,68 @@ func OpenBoxBy(packedBoxCandidateFilename string, base ...string) (Box, error) {
 	}
 }
 
-func openFsBox(bases []string) (Box, error) {
-	callingDir := resolveCallingDir(2)
+func applyOverlays(box Box) (Box, error) {
+	var overlays []string
+	for _, overlay := range filepath.SplitList(os.Getenv(OverlayEnv)) {
+		if overlay != "" {
+			overlays = append(overlays, overlay)
+		}
+	}
+	if len(overlays) == 0 {
+		return box, nil
+	}
+	if onOverlay := OnOverlay; onOverlay != nil {
+		if apply, err := onOverlay(box, overlays); err != nil {
+			_ = box.Close()
+			return nil, err
+		} else if !apply {
+			return box, nil
+		}
+	}
+
+	result := make(CombinedBox, 0, len(overlays)+1)
+	for _, overlay := range overlays {
+		if ob, err := OpenPackedOrFsBox(overlay); err != nil {
+			_ = result.With(box).Close()
+			return nil, err
+		} else {
+			result = result.With(ob)
+		}
+	}
+	return result.With(box), nil
+}
+
+// openFsBox opens the given bases as fs boxes. Relative bases are resolved
+// using runtime.ResolveBase. A base which cannot be found is not an error (the
+// fallback to fs boxes might be refused anyway, see OnFallbackToFsBox); it is
+// resolved against its first candidate and reported as unresolved.
+func openFsBox(bases []string) (_ Box, unresolved []error, _ error) {
+	caller, _ := runtime.CallerOutsideOf(packageName)
 
 	boxes := make(CombinedBox, len(bases))
 	for i, base := range bases {
+		prefix := ""
+		if parts := strings.SplitN(base, "=", 2); len(parts) > 1 {
+			prefix, base = parts[0]+"=", parts[1]
+		}
 		if !filepath.IsAbs(base) {
-			var err error
-			if base, err = filepath.Abs(filepath.Join(callingDir, base)); err != nil {
-				return nil, common.NewPathError("openBox", base, err)
+			if resolved, err := runtime.ResolveBase(base, caller); err == nil {
+				base = resolved
+			} else if nfe, ok := err.(runtime.BaseNotFoundError); ok && len(nfe.Tried) > 0 {
+				unresolved = append(unresolved, err)
+				base = nfe.Tried[0]
+			} else {
+				unresolved = append(unresolved, err)
 			}
 		}
-		if box, err := fs.OpenBox(base); err != nil {
-			return nil, err
+		if box, err := fs.OpenBox(prefix + base); err != nil {
+			return nil, nil, err
 		} else {
 			boxes[i] = box
 		}
 	}
 
-	return boxes, nil
-}
-
-func resolveCallingDir(skipCallerFrames int) string {
-	_, filename, _, _ := sr.Caller(skipCallerFrames + 1)
-	result := filepath.Dir(filename)
-
-	// this little hack courtesy of the `-cover` flag!!
-	cov := filepath.Join("_test", "_obj_test")
-	result = strings.Replace(result, string(filepath.Separator)+cov, "", 1)
-	if result != "" && !filepath.IsAbs(result) {
-		result = filepath.Join(runtime.GoPath(), "src", result)
-	}
-
-	return result
+	return boxes, unresolved, nil
 }
 
 // noinspection GoSnakeCaseUsage
@@ -123,3 +200,30 @@ var OnFallbackToFsBox_Warn = func(packedBoxCandidateFilename string, bases []str
 var OnFallbackToFsBox_Fail = func(packedBoxCandidateFilename string, bases []string, fsBox Box) error {
 	return common.NewPathError("openBox", packedBoxCandidateFilename, common.ErrDoesNotContainBox)
 }
+
+// noinspection GoSnakeCaseUsage
+var OnOverlay_Default = func(box Box, overlays []string) (bool, error) {
+	if AllowFallbackToFsBox {
+		return OnOverlay_Warn(box, overlays)
+	}
+	return OnOverlay_Ignore(box, overlays)
+}
+
+// noinspection GoSnakeCaseUsage
+var OnOverlay_Warn = func(box Box, overlays []string) (bool, error) {
+	log.With("overlays", overlays).
+		Warnf("%s is set. The box will be overlaid by the given boxes.", OverlayEnv)
+	return true, nil
+}
+
+// noinspection GoSnakeCaseUsage
+var OnOverlay_Ignore = func(box Box, overlays []string) (bool, error) {
+	log.With("overlays", overlays).
+		Warnf("%s is set but will be ignored because overlays of boxes are not allowed.", OverlayEnv)
+	return false, nil
+}
+
+// noinspection GoSnakeCaseUsage
+var OnOverlay_Fail = func(box Box, overlays []string) (bool, error) {
+	return false, fmt.Errorf("%s is set but overlays of boxes are not allowed", OverlayEnv)
+}
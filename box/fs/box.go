@@ -5,8 +5,11 @@ import (
 	"github.com/echocat/goxr/common"
 	"github.com/echocat/goxr/entry"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 func OpenBox(base string) (*Box, error) {
@@ -32,15 +35,39 @@ func OpenBox(base string) (*Box, error) {
 	return result, nil
 }
 
+// OpenStrictBox opens the box like OpenBox but with Strict enabled.
+func OpenStrictBox(base string) (*Box, error) {
+	if result, err := OpenBox(base); err != nil {
+		return nil, err
+	} else {
+		result.Strict = true
+		return result, nil
+	}
+}
+
 type Box struct {
 	base              string
 	baseWithSeparator string
 	prefix            string
+
+	// Strict resolves all symlinks of a requested file and refuses it if
+	// the resolved target is outside of the base directory.
+	Strict bool
+
+	// WatchInterval defines how often the base directory is polled while
+	// watched. If not set DefaultWatchInterval is used.
+	WatchInterval time.Duration
+
+	checksums      map[string]checksumCacheEntry
+	checksumsMutex sync.Mutex
 }
 
 func (instance *Box) clean(name string) (string, error) {
 	candidate := entry.CleanPath(name)
 	if instance.prefix != "" {
+		if candidate+"/" == instance.prefix {
+			return "", nil
+		}
 		if !strings.HasPrefix(candidate, instance.prefix) {
 			return "", os.ErrNotExist
 		}
@@ -50,7 +77,48 @@ func (instance *Box) clean(name string) (string, error) {
 }
 
 func (instance *Box) resolvePath(name string) (string, error) {
-	return filepath.Clean(filepath.Join(instance.base, filepath.FromSlash(name))), nil
+	candidate := filepath.Clean(filepath.Join(instance.base, filepath.FromSlash(name)))
+	if !isWithin(candidate, instance.base, instance.baseWithSeparator) {
+		return "", os.ErrNotExist
+	}
+	if !instance.Strict {
+		return candidate, nil
+	}
+	return instance.resolveRealPath(candidate)
+}
+
+func (instance *Box) resolveRealPath(candidate string) (string, error) {
+	realBase, err := filepath.EvalSymlinks(instance.base)
+	if err != nil {
+		return "", err
+	}
+	realCandidate, err := filepath.EvalSymlinks(candidate)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(realCandidate, realBase, realBase+string(filepath.Separator)) {
+		return "", os.ErrPermission
+	}
+	return realCandidate, nil
+}
+
+// resolvedPath returns the path of the given candidate inside of the base
+// after all symlinks are resolved. If it cannot be resolved or it is not
+// located inside of the base the given name will be returned.
+func (instance *Box) resolvedPath(name string, candidate string) string {
+	realBase, err := filepath.EvalSymlinks(instance.base)
+	if err != nil {
+		return name
+	}
+	realCandidate, err := filepath.EvalSymlinks(candidate)
+	if err != nil || !strings.HasPrefix(realCandidate, realBase+string(filepath.Separator)) {
+		return name
+	}
+	return filepath.ToSlash(realCandidate[len(realBase)+1:])
+}
+
+func isWithin(candidate string, base string, baseWithSeparator string) bool {
+	return candidate == base || strings.HasPrefix(candidate, baseWithSeparator)
 }
 
 func (instance *Box) Open(name string) (common.File, error) {
@@ -58,12 +126,10 @@ func (instance *Box) Open(name string) (common.File, error) {
 		return nil, common.NewPathError("open", name, err)
 	} else if candidate, err := instance.resolvePath(cleaned); err != nil {
 		return nil, common.NewPathError("open", name, err)
-	} else if candidate != instance.base && !strings.HasPrefix(candidate, instance.baseWithSeparator) {
-		return nil, common.NewPathError("open", name, os.ErrNotExist)
 	} else if f, err := os.Open(candidate); err != nil {
 		return nil, common.NewPathError("open", name, err)
 	} else {
-		return &file{f, cleaned}, nil
+		return &file{f, instance.resolvedPath(cleaned, candidate), instance}, nil
 	}
 }
 
@@ -72,14 +138,10 @@ func (instance *Box) Info(name string) (common.FileInfo, error) {
 		return nil, common.NewPathError("info", name, err)
 	} else if candidate, err := instance.resolvePath(cleaned); err != nil {
 		return nil, common.NewPathError("info", name, err)
-	} else if candidate != instance.base && !strings.HasPrefix(candidate, instance.baseWithSeparator) {
-		return nil, common.NewPathError("info", name, os.ErrNotExist)
 	} else if fi, err := os.Stat(candidate); err != nil {
 		return nil, common.NewPathError("info", name, err)
-	} else if fi.IsDir() {
-		return nil, common.NewPathError("info", name, os.ErrNotExist)
 	} else {
-		return &fileInfo{fi, candidate}, nil
+		return instance.newFileInfo(fi, cleaned, candidate), nil
 	}
 }
 
@@ -97,6 +159,13 @@ func (instance *Box) ForEach(predicate common.FilePredicate, callback func(commo
 		if info.IsDir() {
 			return nil
 		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = instance.statSymlink(path); err != nil {
+				return err
+			} else if info == nil || info.IsDir() {
+				return nil
+			}
+		}
 		fullPath, err := filepath.Abs(path)
 		if err != nil {
 			return err
@@ -112,7 +181,7 @@ func (instance *Box) ForEach(predicate common.FilePredicate, callback func(commo
 				return nil
 			}
 		}
-		return callback(&fileInfo{info, p})
+		return callback(instance.newFileInfo(info, p, fullPath))
 	}); err != nil {
 		return fmt.Errorf("cannot iterate over box %s: %v", instance.base, err)
 	}
@@ -120,6 +189,25 @@ func (instance *Box) ForEach(predicate common.FilePredicate, callback func(commo
 	return nil
 }
 
+// statSymlink returns the info of the target of the given symlink. If it
+// does not exist or is refused by Strict nil will be returned.
+func (instance *Box) statSymlink(path string) (os.FileInfo, error) {
+	target := path
+	if instance.Strict {
+		var err error
+		if target, err = instance.resolveRealPath(path); os.IsNotExist(err) || os.IsPermission(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if fi, err := os.Stat(target); os.IsNotExist(err) {
+		return nil, nil
+	} else {
+		return fi, err
+	}
+}
+
 func (instance *Box) Close() error {
 	return nil
 }
@@ -127,6 +215,26 @@ func (instance *Box) Close() error {
 type file struct {
 	*os.File
 	path string
+	box  *Box
+}
+
+func (instance *file) Readdir(count int) ([]os.FileInfo, error) {
+	fis, err := instance.File.Readdir(count)
+	result := make([]os.FileInfo, 0, len(fis))
+	for _, fi := range fis {
+		childPath := path.Join(instance.path, fi.Name())
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if target, sErr := instance.box.statSymlink(filepath.Join(instance.File.Name(), fi.Name())); sErr != nil {
+				return result, common.NewPathError("readdir", instance.path, sErr)
+			} else if target == nil {
+				continue
+			} else {
+				fi = renamedFileInfo{target, fi.Name()}
+			}
+		}
+		result = append(result, instance.box.newFileInfo(fi, childPath, filepath.Join(instance.File.Name(), fi.Name())))
+	}
+	return result, err
 }
 
 func (instance *file) Stat() (os.FileInfo, error) {
@@ -138,14 +246,34 @@ func (instance *file) GetFileInfo() (common.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &fileInfo{fi, instance.path}, nil
+	return instance.box.newFileInfo(fi, instance.path, instance.File.Name()), nil
 }
 
 type fileInfo struct {
 	os.FileInfo
-	path string
+	path     string
+	filename string
+	box      *Box
+}
+
+func (instance *Box) newFileInfo(fi os.FileInfo, path string, filename string) *fileInfo {
+	return &fileInfo{
+		FileInfo: fi,
+		path:     path,
+		filename: filename,
+		box:      instance,
+	}
 }
 
 func (instance *fileInfo) Path() string {
 	return instance.path
 }
+
+type renamedFileInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (instance renamedFileInfo) Name() string {
+	return instance.name
+}
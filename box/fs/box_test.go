@@ -0,0 +1,159 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_Box_strict(t *testing.T) {
+	root, outside := boxTestDirsForT(t)
+	defer removeAllForT(root, t)
+	defer removeAllForT(outside, t)
+
+	box, err := OpenStrictBox(root)
+	assert.NoError(t, err)
+
+	f, err := box.Open("dir/a.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	f, err = box.Open("inside.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	_, err = box.Open("outside.txt")
+	assert.True(t, os.IsPermission(err))
+
+	_, err = box.Info("outside.txt")
+	assert.True(t, os.IsPermission(err))
+
+	_, err = box.Open("../outside.txt")
+	assert.True(t, os.IsNotExist(err))
+
+	var paths []string
+	assert.NoError(t, box.ForEach(nil, func(fi common.FileInfo) error {
+		paths = append(paths, fi.Path())
+		return nil
+	}))
+	sort.Strings(paths)
+	assert.Equal(t, []string{"dir/a.txt", "inside.txt"}, paths)
+
+	box.Strict = false
+	f, err = box.Open("outside.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+}
+
+func Test_Box_directories(t *testing.T) {
+	root, outside := boxTestDirsForT(t)
+	defer removeAllForT(root, t)
+	defer removeAllForT(outside, t)
+
+	box, err := OpenStrictBox("foo=" + root)
+	assert.NoError(t, err)
+
+	fi, err := box.Info("foo/dir")
+	assert.NoError(t, err)
+	assert.True(t, fi.IsDir())
+	assert.Equal(t, "dir", fi.Path())
+
+	f, err := box.Open("foo")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, f.Close())
+	}()
+	fis, err := f.Readdir(-1)
+	assert.NoError(t, err)
+
+	names := map[string]bool{}
+	for _, fi := range fis {
+		names[fi.(common.FileInfo).Path()] = fi.IsDir()
+	}
+	assert.Equal(t, map[string]bool{"dir": true, "inside.txt": false}, names)
+}
+
+func boxTestDirsForT(t *testing.T) (root string, outside string) {
+	root, err := ioutil.TempDir("", "goxr-fs-box-")
+	assert.NoError(t, err)
+	outside, err = ioutil.TempDir("", "goxr-fs-box-outside-")
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "dir", "a.txt"), []byte("a"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+	assert.NoError(t, os.Symlink(filepath.Join("dir", "a.txt"), filepath.Join(root, "inside.txt")))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "outside.txt")))
+	return root, outside
+}
+
+func removeAllForT(p string, t *testing.T) {
+	if err := os.RemoveAll(p); err != nil {
+		t.Errorf("cannot remove %s: %v", p, err)
+	}
+}
+
+func Test_Box_checksum(t *testing.T) {
+	root, outside := boxTestDirsForT(t)
+	defer removeAllForT(root, t)
+	defer removeAllForT(outside, t)
+
+	box, err := OpenBox(root)
+	assert.NoError(t, err)
+
+	checksumOf := func(pathname string) string {
+		fi, err := box.Info(pathname)
+		assert.NoError(t, err)
+		return fi.(common.ExtendedFileInfo).ChecksumString()
+	}
+
+	assert.Equal(t, entry.Sha256Checksum(sha256.Sum256([]byte("a"))).String(), checksumOf("dir/a.txt"))
+	assert.Equal(t, checksumOf("dir/a.txt"), checksumOf("inside.txt"))
+	assert.Equal(t, "", checksumOf("dir"))
+
+	filename := filepath.Join(root, "dir", "a.txt")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("bc"), 0644))
+	assert.Equal(t, entry.Sha256Checksum(sha256.Sum256([]byte("bc"))).String(), checksumOf("dir/a.txt"))
+}
+
+func Test_Box_watch(t *testing.T) {
+	root, outside := boxTestDirsForT(t)
+	defer removeAllForT(root, t)
+	defer removeAllForT(outside, t)
+
+	box, err := OpenBox("foo=" + root)
+	assert.NoError(t, err)
+	box.WatchInterval = 10 * time.Millisecond
+
+	events := make(chan common.ChangeEvent, 10)
+	closer, err := box.Watch(func(event common.ChangeEvent) {
+		events <- event
+	})
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, closer.Close())
+	}()
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "new.txt"), []byte("new"), 0644))
+	assert.Equal(t, common.ChangeEvent{Type: common.ChangeTypeCreated, Path: "foo/new.txt"}, receiveEventForT(events, t))
+
+	assert.NoError(t, os.Remove(filepath.Join(root, "new.txt")))
+	assert.Equal(t, common.ChangeEvent{Type: common.ChangeTypeRemoved, Path: "foo/new.txt"}, receiveEventForT(events, t))
+}
+
+func receiveEventForT(events chan common.ChangeEvent, t *testing.T) common.ChangeEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout while waiting for change event")
+		return common.ChangeEvent{}
+	}
+}
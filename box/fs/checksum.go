@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"github.com/echocat/goxr/entry"
+	"io"
+	"os"
+	"time"
+)
+
+type checksumCacheEntry struct {
+	modTime  time.Time
+	size     int64
+	checksum entry.Sha256Checksum
+}
+
+// ChecksumString returns the SHA-256 checksum of the file in the same format
+// as entry.Entry.ChecksumString(). It is calculated on first access and cached
+// as long as modification time and size of the file does not change.
+// For directories or files which cannot be read an empty string will be returned.
+func (instance *fileInfo) ChecksumString() string {
+	if instance.IsDir() || instance.box == nil || instance.filename == "" {
+		return ""
+	}
+	if checksum, err := instance.box.checksumOf(instance.filename, instance.FileInfo); err != nil {
+		return ""
+	} else {
+		return checksum.String()
+	}
+}
+
+func (instance *Box) checksumOf(filename string, fi os.FileInfo) (entry.Sha256Checksum, error) {
+	instance.checksumsMutex.Lock()
+	cached, ok := instance.checksums[filename]
+	instance.checksumsMutex.Unlock()
+	if ok && cached.size == fi.Size() && cached.modTime.Equal(fi.ModTime()) {
+		return cached.checksum, nil
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return entry.Sha256Checksum{}, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return entry.Sha256Checksum{}, err
+	}
+	result := entry.Sha256Checksum{}
+	copy(result[:], hash.Sum(nil))
+
+	instance.checksumsMutex.Lock()
+	if instance.checksums == nil {
+		instance.checksums = make(map[string]checksumCacheEntry)
+	}
+	instance.checksums[filename] = checksumCacheEntry{
+		modTime:  fi.ModTime(),
+		size:     fi.Size(),
+		checksum: result,
+	}
+	instance.checksumsMutex.Unlock()
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"github.com/echocat/goxr/common"
+	"io"
+	"time"
+)
+
+const DefaultWatchInterval = 500 * time.Millisecond
+
+type watchState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch polls the base directory of this box every WatchInterval and reports
+// every created, modified and removed file to the given listener until the
+// returned io.Closer is closed.
+func (instance *Box) Watch(listener common.ChangeListener) (io.Closer, error) {
+	interval := instance.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	previous, err := instance.watchSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if current, err := instance.watchSnapshot(); err == nil {
+					instance.reportChanges(previous, current, listener)
+					previous = current
+				}
+			}
+		}
+	}()
+
+	return common.NewOnceCloser(func() error {
+		close(done)
+		return nil
+	}), nil
+}
+
+func (instance *Box) watchSnapshot() (map[string]watchState, error) {
+	result := make(map[string]watchState)
+	if err := instance.ForEach(nil, func(fi common.FileInfo) error {
+		result[fi.Path()] = watchState{
+			modTime: fi.ModTime(),
+			size:    fi.Size(),
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (instance *Box) reportChanges(previous, current map[string]watchState, listener common.ChangeListener) {
+	for p, state := range current {
+		if old, ok := previous[p]; !ok {
+			listener(common.ChangeEvent{Type: common.ChangeTypeCreated, Path: instance.prefix + p})
+		} else if old.size != state.size || !old.modTime.Equal(state.modTime) {
+			listener(common.ChangeEvent{Type: common.ChangeTypeModified, Path: instance.prefix + p})
+		}
+	}
+	for p := range previous {
+		if _, ok := current[p]; !ok {
+			listener(common.ChangeEvent{Type: common.ChangeTypeRemoved, Path: instance.prefix + p})
+		}
+	}
+}
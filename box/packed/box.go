@@ -57,19 +57,19 @@ func (instance *Box) resolvePath(name string) (string, error) {
 func (instance *Box) Open(pathname string) (common.File, error) {
 	if candidate, err := instance.resolvePath(pathname); err != nil {
 		return nil, common.NewPathError("open", pathname, err)
-	} else if e := instance.Entries.Find(candidate); e == nil {
+	} else if e, resolved := instance.resolveEntry(candidate); e == nil {
 		return nil, common.NewPathError("open", pathname, os.ErrNotExist)
 	} else if instance.EntryToFileTransformer == nil {
 		return nil, common.NewPathError("open", pathname, entry.ErrNoToFileTransformerProvided)
 	} else {
-		return instance.EntryToFileTransformer("open", candidate, e)
+		return instance.EntryToFileTransformer("open", resolved, e)
 	}
 }
 
 func (instance *Box) Info(pathname string) (common.FileInfo, error) {
 	if candidate, err := instance.resolvePath(pathname); err != nil {
 		return nil, common.NewPathError("open", pathname, err)
-	} else if e := instance.Entries.Find(candidate); e == nil {
+	} else if e, _ := instance.resolveEntry(candidate); e == nil {
 		return nil, common.NewPathError("info", pathname, os.ErrNotExist)
 	} else {
 		return *e, nil
@@ -0,0 +1,149 @@
+package packed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"text/template"
+)
+
+type ContentTransformer interface {
+	TransformContent(target TargetEntry, source io.Reader) (io.Reader, error)
+}
+
+type ContentTransformerFunc func(target TargetEntry, source io.Reader) (io.Reader, error)
+
+func (instance ContentTransformerFunc) TransformContent(target TargetEntry, source io.Reader) (io.Reader, error) {
+	return instance(target, source)
+}
+
+type ContentTransformerFactory func(box *Box) ContentTransformer
+
+var (
+	MinifyJsonTransformer      = ContentTransformerFunc(minifyJson)
+	MinifyHtmlTransformer      = ContentTransformerFunc(minifyHtml)
+	StripSourceMapsTransformer = ContentTransformerFunc(stripSourceMaps)
+
+	ContentTransformerFactories = map[string]ContentTransformerFactory{
+		"minifyJson":      func(*Box) ContentTransformer { return MinifyJsonTransformer },
+		"minifyHtml":      func(*Box) ContentTransformer { return MinifyHtmlTransformer },
+		"stripSourceMaps": func(*Box) ContentTransformer { return StripSourceMapsTransformer },
+		"template":        NewTemplateTransformer,
+	}
+
+	htmlPreservedElements        = map[string]bool{"pre": true, "textarea": true, "script": true, "style": true}
+	htmlWhitespacePattern        = regexp.MustCompile(`\s+`)
+	sourceMapLineCommentPattern  = regexp.MustCompile(`(?m)^[ \t]*//[#@][ \t]*sourceMappingURL=.*(?:\r?\n)?`)
+	sourceMapBlockCommentPattern = regexp.MustCompile(`/\*[#@][ \t]*sourceMappingURL=[^*]*\*/`)
+)
+
+func ContentTransformerNames() []string {
+	result := make([]string, 0, len(ContentTransformerFactories))
+	for name := range ContentTransformerFactories {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func NewContentTransformer(name string, box *Box) (ContentTransformer, error) {
+	if factory, ok := ContentTransformerFactories[name]; !ok {
+		return nil, fmt.Errorf("unknown content transformer '%s' - supported are: %v", name, ContentTransformerNames())
+	} else {
+		return factory(box), nil
+	}
+}
+
+// NewTemplateTransformer executes the content as text/template with the box as data.
+// This can be used to inject for example {{.Version}} or {{.Revision}} of the box.
+func NewTemplateTransformer(box *Box) ContentTransformer {
+	return ContentTransformerFunc(func(target TargetEntry, source io.Reader) (io.Reader, error) {
+		if b, err := ioutil.ReadAll(source); err != nil {
+			return nil, err
+		} else if tmpl, err := template.New(target.Filename).Option("missingkey=error").Parse(string(b)); err != nil {
+			return nil, err
+		} else {
+			buf := new(bytes.Buffer)
+			if err := tmpl.Execute(buf, box); err != nil {
+				return nil, err
+			}
+			return buf, nil
+		}
+	})
+}
+
+func minifyJson(_ TargetEntry, source io.Reader) (io.Reader, error) {
+	if b, err := ioutil.ReadAll(source); err != nil {
+		return nil, err
+	} else {
+		buf := new(bytes.Buffer)
+		if err := json.Compact(buf, b); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+}
+
+// minifyHtml removes comments (except conditional ones) and collapses
+// whitespace of text. Tags (including their attribute values) and the content
+// of pre, textarea, script and style elements are preserved as they are.
+func minifyHtml(_ TargetEntry, source io.Reader) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	var text []byte
+	flushText := func() {
+		buf.Write(htmlWhitespacePattern.ReplaceAllFunc(text, func(ws []byte) []byte {
+			if bytes.IndexByte(ws, '\n') >= 0 {
+				return []byte{'\n'}
+			}
+			return []byte{' '}
+		}))
+		text = text[:0]
+	}
+
+	var preserved []string
+	z := html.NewTokenizer(source)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+		raw := z.Raw()
+		if tt == html.TextToken && len(preserved) == 0 {
+			text = append(text, raw...)
+			continue
+		}
+		if tt == html.CommentToken && !bytes.HasPrefix(raw, []byte("<!--[")) {
+			continue
+		}
+		flushText()
+		buf.Write(raw)
+		if tt == html.StartTagToken || tt == html.EndTagToken {
+			name, _ := z.TagName()
+			if tt == html.StartTagToken && htmlPreservedElements[string(name)] {
+				preserved = append(preserved, string(name))
+			} else if tt == html.EndTagToken && len(preserved) > 0 && preserved[len(preserved)-1] == string(name) {
+				preserved = preserved[:len(preserved)-1]
+			}
+		}
+	}
+	flushText()
+	return bytes.NewReader(bytes.TrimSpace(buf.Bytes())), nil
+}
+
+func stripSourceMaps(_ TargetEntry, source io.Reader) (io.Reader, error) {
+	if b, err := ioutil.ReadAll(source); err != nil {
+		return nil, err
+	} else {
+		b = sourceMapLineCommentPattern.ReplaceAll(b, nil)
+		b = sourceMapBlockCommentPattern.ReplaceAll(b, nil)
+		return bytes.NewReader(b), nil
+	}
+}
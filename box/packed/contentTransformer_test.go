@@ -0,0 +1,77 @@
+package packed
+
+import (
+	"crypto/sha256"
+	"github.com/echocat/goxr/entry"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_ContentTransformers(t *testing.T) {
+	box := &Box{Name: "test", Version: "1.2.3"}
+	addCase := func(name string, transformerName string, in string, expected string) {
+		t.Run(name, func(t *testing.T) {
+			transformer, err := NewContentTransformer(transformerName, box)
+			assert.NoError(t, err)
+			r, err := transformer.TransformContent(TargetEntry{Filename: "foo"}, strings.NewReader(in))
+			assert.NoError(t, err)
+			actual, err := ioutil.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, string(actual))
+		})
+	}
+
+	addCase("minifyJson", "minifyJson",
+		"{\n  \"a\": [1, 2],\n  \"b\": \"c d\"\n}\n",
+		`{"a":[1,2],"b":"c d"}`)
+	addCase("minifyHtml", "minifyHtml",
+		"<html>\n  <!-- comment -->\n  <body>\n    <b>a</b>   <i>b</i>\n    <pre>  x\n   y</pre>\n    <!--[if IE]>ie<![endif]-->\n  </body>\n</html>\n",
+		"<html>\n<body>\n<b>a</b> <i>b</i>\n<pre>  x\n   y</pre>\n<!--[if IE]>ie<![endif]-->\n</body>\n</html>")
+	addCase("minifyHtmlNestedPreserved", "minifyHtml",
+		"<pre>  a\n<script>x  y</script>\n  b  </pre>   <i>c</i>",
+		"<pre>  a\n<script>x  y</script>\n  b  </pre> <i>c</i>")
+	addCase("minifyHtmlAttributes", "minifyHtml",
+		"<a title=\"x   y\"   href=\"#\">a   b</a>",
+		"<a title=\"x   y\"   href=\"#\">a b</a>")
+	addCase("stripSourceMaps", "stripSourceMaps",
+		"alert(1);\n//# sourceMappingURL=app.js.map\n.a{}/*# sourceMappingURL=app.css.map */",
+		"alert(1);\n.a{}")
+	addCase("template", "template",
+		`var version = "{{.Version}}";`,
+		`var version = "1.2.3";`)
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := NewContentTransformer("foo", box)
+		assert.Error(t, err)
+	})
+}
+
+func Test_Writer_withTransformers(t *testing.T) {
+	fn := tempFileWithBytesOf()
+	defer deletePathForT(fn, t)
+
+	upper := ContentTransformerFunc(func(_ TargetEntry, source io.Reader) (io.Reader, error) {
+		b, err := ioutil.ReadAll(source)
+		return strings.NewReader(strings.ToUpper(string(b))), err
+	})
+
+	writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(TargetEntry{
+		Filename:     "data.json",
+		Transformers: []ContentTransformer{MinifyJsonTransformer, upper},
+	}, strings.NewReader(`{ "a": "b" }`)))
+	assert.NoError(t, writer.Close())
+
+	box, err := OpenBox(fn)
+	assert.NoError(t, err)
+	defer closeForT(box, t)
+
+	fi, err := box.Info("data.json")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), fi.Size())
+	assert.Equal(t, entry.Sha256Checksum(sha256.Sum256([]byte(`{"A":"B"}`))), fi.(entry.Entry).Checksum)
+}
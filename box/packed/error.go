@@ -7,4 +7,5 @@ import (
 var (
 	ErrInvalidHeaderVersion = errors.New("invalid header version")
 	ErrActiveEntryWriter    = errors.New("there is another entry writer active and not closed")
+	ErrSymlinkLoop          = errors.New("symlink loop detected")
 )
@@ -0,0 +1,71 @@
+package packed
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"path"
+	"strings"
+)
+
+const (
+	// AssetManifestFilename is the default location inside the box of the manifest
+	// which maps logical paths to their fingerprinted paths. It is goxr specific
+	// to not collide with files of the application like a web app manifest.json.
+	AssetManifestFilename = ".goxr/fingerprints.json"
+	// MetaLogicalPath is stored in the entry.Meta of every fingerprinted entry.
+	MetaLogicalPath = "logicalPath"
+	// FingerprintLength is the amount of hex characters of the checksum used as fingerprint.
+	FingerprintLength = 6
+)
+
+// FingerprintedFilename inserts the fingerprint of the given checksum before the
+// extension of the given filename: app.js -> app.3f2a9c.js
+func FingerprintedFilename(filename string, checksum entry.Sha256Checksum) string {
+	fingerprint := hex.EncodeToString(checksum[:])[:FingerprintLength]
+	dir, base := path.Split(filename)
+	if i := strings.LastIndexByte(base, '.'); i > 0 {
+		return dir + base[:i] + "." + fingerprint + base[i:]
+	}
+	return dir + base + "." + fingerprint
+}
+
+func (instance *Writer) Fingerprints() map[string]string {
+	result := make(map[string]string, len(instance.fingerprints))
+	for logical, fingerprinted := range instance.fingerprints {
+		result[logical] = fingerprinted
+	}
+	return result
+}
+
+func (instance *Writer) fingerprint(e *entry.Entry) error {
+	logical := e.Filename
+	fingerprinted := FingerprintedFilename(logical, e.Checksum)
+	if err := instance.box.Entries.Add(fingerprinted, e); err != nil {
+		return err
+	} else if err := instance.box.Entries.Remove(logical); err != nil {
+		return err
+	}
+	e.Filename = fingerprinted
+	e.Meta[MetaLogicalPath] = logical
+	if instance.fingerprints == nil {
+		instance.fingerprints = make(map[string]string)
+	}
+	instance.fingerprints[logical] = fingerprinted
+	return nil
+}
+
+func (instance *Writer) writeAssetManifest() error {
+	if len(instance.fingerprints) == 0 || instance.AssetManifestFilename == "" {
+		return nil
+	}
+	if b, err := json.MarshalIndent(instance.fingerprints, "", "  "); err != nil {
+		return common.NewPathError("writeAssetManifest", instance.AssetManifestFilename, err)
+	} else {
+		return instance.Write(TargetEntry{
+			Filename: instance.AssetManifestFilename,
+		}, bytes.NewReader(b))
+	}
+}
@@ -0,0 +1,66 @@
+package packed
+
+import (
+	"encoding/json"
+	"github.com/echocat/goxr/entry"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_FingerprintedFilename(t *testing.T) {
+	checksum := entry.Sha256Checksum{0x3f, 0x2a, 0x9c, 0xff}
+
+	assert.Equal(t, "app.3f2a9c.js", FingerprintedFilename("app.js", checksum))
+	assert.Equal(t, "static/app.min.3f2a9c.js", FingerprintedFilename("static/app.min.js", checksum))
+	assert.Equal(t, "LICENSE.3f2a9c", FingerprintedFilename("LICENSE", checksum))
+	assert.Equal(t, ".env.3f2a9c", FingerprintedFilename(".env", checksum))
+}
+
+func Test_Writer_fingerprint(t *testing.T) {
+	fn := tempFileWithBytesOf()
+	defer deletePathForT(fn, t)
+
+	writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "static/app.js", Fingerprint: true}, strings.NewReader("alert(1)")))
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "index.html"}, strings.NewReader("<html></html>")))
+	fingerprints := writer.Fingerprints()
+	assert.NoError(t, writer.Close())
+
+	box, err := OpenBox(fn)
+	assert.NoError(t, err)
+	defer closeForT(box, t)
+
+	fingerprinted := fingerprints["static/app.js"]
+	assert.Regexp(t, `^static/app\.[0-9a-f]{6}\.js$`, fingerprinted)
+
+	_, err = box.Info("static/app.js")
+	assert.Error(t, err)
+	fi, err := box.Info(fingerprinted)
+	assert.NoError(t, err)
+	assert.Equal(t, "static/app.js", fi.(entry.Entry).Meta[MetaLogicalPath])
+	assert.Equal(t, FingerprintedFilename("static/app.js", fi.(entry.Entry).Checksum), fingerprinted)
+
+	f, err := box.Open(AssetManifestFilename)
+	assert.NoError(t, err)
+	defer closeForT(f, t)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	var manifest map[string]string
+	assert.NoError(t, json.Unmarshal(b, &manifest))
+	assert.Equal(t, map[string]string{"static/app.js": fingerprinted}, manifest)
+}
+
+func Test_Writer_fingerprint_failureDoesNotBlockWriter(t *testing.T) {
+	fn := tempFileWithBytesOf()
+	defer deletePathForT(fn, t)
+
+	writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "app.js", Fingerprint: true}, strings.NewReader("alert(1)")))
+	assert.Error(t, writer.Write(TargetEntry{Filename: "app.js", Fingerprint: true}, strings.NewReader("alert(1)")))
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "index.html"}, strings.NewReader("<html></html>")))
+	assert.NoError(t, writer.Close())
+}
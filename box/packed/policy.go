@@ -0,0 +1,135 @@
+package packed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr/common"
+	"path"
+	"sort"
+	"strings"
+)
+
+const PolicyReportLargestEntries = 10
+
+type Policy struct {
+	MaxTotalSize common.FileSize `yaml:"maxTotalSize,omitempty"`
+	MaxEntrySize common.FileSize `yaml:"maxEntrySize,omitempty"`
+	Forbidden    []string        `yaml:"forbidden,omitempty"`
+}
+
+func (instance Policy) Merge(with Policy) Policy {
+	result := instance
+	if with.MaxTotalSize > 0 {
+		result.MaxTotalSize = with.MaxTotalSize
+	}
+	if with.MaxEntrySize > 0 {
+		result.MaxEntrySize = with.MaxEntrySize
+	}
+	result.Forbidden = append(append([]string{}, instance.Forbidden...), with.Forbidden...)
+	return result
+}
+
+func (instance Policy) Validate() error {
+	for i, pattern := range instance.Forbidden {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf(`forbidden[%d] = "%s" - pattern invalid: %v`, i, pattern, err)
+		}
+	}
+	return nil
+}
+
+func (instance Policy) NewChecker() (*PolicyChecker, error) {
+	if err := instance.Validate(); err != nil {
+		return nil, err
+	}
+	return &PolicyChecker{policy: instance}, nil
+}
+
+type PolicyChecker struct {
+	policy     Policy
+	totalSize  common.FileSize
+	entries    []policyEntry
+	violations []string
+}
+
+type policyEntry struct {
+	filename string
+	size     common.FileSize
+}
+
+// Intercept rejects the given candidate if its target is forbidden by the
+// policy. It has to be the last interceptor applied to a candidate. Sizes are
+// not checked here but of every entry actually written (see Record).
+func (instance *PolicyChecker) Intercept(candidate *WriteCandidate) error {
+	if !candidate.Accept {
+		return nil
+	}
+	filename := candidate.Target.Filename
+	if pattern := instance.forbiddenPatternFor(filename); pattern != "" {
+		instance.violations = append(instance.violations, fmt.Sprintf(`%s is forbidden (matches "%s")`, filename, pattern))
+		candidate.Accept = false
+	}
+	return nil
+}
+
+// Record records an entry of the given size which was written to the box. It
+// is called by the Writer for every entry if Writer.PolicyChecker is set.
+func (instance *PolicyChecker) Record(filename string, size common.FileSize) {
+	if max := instance.policy.MaxEntrySize; max > 0 && size > max {
+		instance.violations = append(instance.violations, fmt.Sprintf(`%s has a size of %v which exceeds the maximum entry size of %v`, filename, size, max))
+	}
+	instance.entries = append(instance.entries, policyEntry{filename, size})
+	instance.totalSize += size
+}
+
+func (instance *PolicyChecker) forbiddenPatternFor(filename string) string {
+	for _, pattern := range instance.policy.Forbidden {
+		candidate := filename
+		if !strings.Contains(pattern, "/") {
+			candidate = path.Base(filename)
+		}
+		if ok, _ := path.Match(pattern, candidate); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+func (instance *PolicyChecker) TotalSize() common.FileSize {
+	return instance.totalSize
+}
+
+// Check returns an error which contains every violation and the largest entries if
+// the policy was violated by any of the intercepted candidates.
+func (instance *PolicyChecker) Check() error {
+	violations := instance.violations
+	if max := instance.policy.MaxTotalSize; max > 0 && instance.totalSize > max {
+		violations = append(violations, fmt.Sprintf(`total size of %v exceeds the maximum total size of %v`, instance.totalSize, max))
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	common.MustWritef(buf, "box policy violated:")
+	for i, violation := range violations {
+		common.MustWritef(buf, "\n  %d. %s", i+1, violation)
+	}
+
+	entries := make([]policyEntry, len(instance.entries))
+	copy(entries, instance.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].size > entries[j].size
+	})
+	if len(entries) > PolicyReportLargestEntries {
+		entries = entries[:PolicyReportLargestEntries]
+	}
+	if len(entries) > 0 {
+		common.MustWritef(buf, "\nlargest entries:")
+		for _, e := range entries {
+			common.MustWritef(buf, "\n  %10v  %s", e.size, e.filename)
+		}
+	}
+	return errors.New(buf.String())
+}
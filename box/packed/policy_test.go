@@ -0,0 +1,102 @@
+package packed
+
+import (
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_PolicyChecker(t *testing.T) {
+	var maxEntrySize, maxTotalSize common.FileSize
+	assert.NoError(t, maxEntrySize.Set("1KB"))
+	assert.NoError(t, maxTotalSize.Set("2KB"))
+	checker, err := Policy{
+		MaxTotalSize: maxTotalSize,
+		MaxEntrySize: maxEntrySize,
+		Forbidden:    []string{".env", "*.pem", "secrets/*"},
+	}.NewChecker()
+	assert.NoError(t, err)
+
+	intercept := func(filename string, size int64) bool {
+		candidate := &WriteCandidate{
+			Accept:         true,
+			SourceFilename: filename,
+			SourceFileInfo: policyTestFileInfo(size),
+			Target:         &TargetEntry{Filename: filename},
+		}
+		assert.NoError(t, checker.Intercept(candidate))
+		if candidate.Accept {
+			checker.Record(filename, common.FileSize(size))
+		}
+		return candidate.Accept
+	}
+
+	assert.True(t, intercept("index.html", 1000))
+	assert.True(t, intercept("app.js", 1000))
+	assert.False(t, intercept("config/.env", 10))
+	assert.False(t, intercept("certs/server.pem", 10))
+	assert.False(t, intercept("secrets/token", 10))
+	assert.True(t, intercept("other/secrets/token", 10))
+	assert.True(t, intercept("video.mp4", 5000))
+	assert.True(t, intercept("style.css", 500))
+
+	assert.Equal(t, common.FileSize(7510), checker.TotalSize())
+
+	err = checker.Check()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `config/.env is forbidden (matches ".env")`)
+	assert.Contains(t, err.Error(), `certs/server.pem is forbidden (matches "*.pem")`)
+	assert.Contains(t, err.Error(), `secrets/token is forbidden (matches "secrets/*")`)
+	assert.Contains(t, err.Error(), `video.mp4 has a size of 4.9 KB which exceeds the maximum entry size of 1024 B`)
+	assert.Contains(t, err.Error(), `total size of 7.3 KB exceeds the maximum total size of 2.0 KB`)
+	assert.Regexp(t, `largest entries:\n\s+4\.9 KB\s+video\.mp4\n\s+1000 B\s+index\.html`, err.Error())
+}
+
+func Test_PolicyChecker_withoutViolations(t *testing.T) {
+	checker, err := Policy{}.NewChecker()
+	assert.NoError(t, err)
+	assert.NoError(t, checker.Intercept(&WriteCandidate{
+		Accept:         true,
+		SourceFileInfo: policyTestFileInfo(666),
+		Target:         &TargetEntry{Filename: ".env"},
+	}))
+	assert.NoError(t, checker.Check())
+}
+
+func Test_Writer_PolicyChecker(t *testing.T) {
+	var maxTotalSize common.FileSize
+	assert.NoError(t, maxTotalSize.Set("1KB"))
+	checker, err := Policy{MaxTotalSize: maxTotalSize}.NewChecker()
+	assert.NoError(t, err)
+
+	fn := tempFileWithBytesOf()
+	defer deletePathForT(fn, t)
+	writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+	assert.NoError(t, err)
+	writer.PolicyChecker = checker
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "a.txt"}, strings.NewReader(strings.Repeat("a", 600))))
+	assert.NoError(t, writer.Write(TargetEntry{
+		Filename: "b.txt",
+		Transformers: []ContentTransformer{ContentTransformerFunc(func(TargetEntry, io.Reader) (io.Reader, error) {
+			return strings.NewReader(strings.Repeat("b", 600)), nil
+		})},
+	}, strings.NewReader("b")))
+	assert.NoError(t, writer.Abort())
+
+	assert.Equal(t, common.FileSize(1200), checker.TotalSize())
+	assert.Error(t, checker.Check())
+	assert.Equal(t, int64(0), fileSizeForT(fn, t))
+}
+
+type policyTestFileInfo int64
+
+func (instance policyTestFileInfo) Name() string       { return "" }
+func (instance policyTestFileInfo) Size() int64        { return int64(instance) }
+func (instance policyTestFileInfo) Mode() os.FileMode  { return 0644 }
+func (instance policyTestFileInfo) ModTime() time.Time { return time.Time{} }
+func (instance policyTestFileInfo) IsDir() bool        { return false }
+func (instance policyTestFileInfo) Sys() interface{}   { return nil }
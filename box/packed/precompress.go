@@ -0,0 +1,82 @@
+package packed
+
+import (
+	"bytes"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io"
+	"mime"
+	"path"
+	"sort"
+)
+
+// DefaultPrecompressMinSize is the minimum size of entries which are
+// precompressed. Smaller entries are not worth it.
+const DefaultPrecompressMinSize = int64(256)
+
+// PrecompressPredicate decides if the given entry should be precompressed.
+type PrecompressPredicate func(e *entry.Entry) bool
+
+// DefaultPrecompressPredicate accepts all entries of at least
+// DefaultPrecompressMinSize bytes which have a compressible mime type
+// (see common.DefaultCompressibleMimeTypes).
+func DefaultPrecompressPredicate(e *entry.Entry) bool {
+	return e.Length >= DefaultPrecompressMinSize &&
+		common.IsCompressibleMimeType(mime.TypeByExtension(path.Ext(e.Filename)), common.DefaultCompressibleMimeTypes)
+}
+
+// WritePrecompressed stores for every already written entry which is accepted
+// by the given predicate a sibling for every given encoding which contains the
+// encoded content (for example index.html.br). Siblings which are not smaller
+// than the original entry are not stored. goxr-server serves these siblings
+// instead of the original entry if the client accepts the encoding.
+func (instance *Writer) WritePrecompressed(encodings []common.Encoding, predicate PrecompressPredicate) error {
+	if predicate == nil {
+		predicate = DefaultPrecompressPredicate
+	}
+	names := make([]string, 0, len(instance.box.Entries))
+	for name := range instance.box.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := instance.box.Entries[name]
+		if _, encoded := common.EncodingOfFilename(name); encoded || e.Meta[MetaLinkTarget] != nil || !predicate(e) {
+			continue
+		}
+		for _, encoding := range encodings {
+			if err := instance.writePrecompressed(e, encoding); err != nil {
+				return common.NewPathError("writePrecompressed", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (instance *Writer) writePrecompressed(e *entry.Entry, encoding common.Encoding) error {
+	target := e.Filename + encoding.Extension()
+	if instance.box.Entries.Find(target) != nil {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	if w, err := encoding.NewWriter(buf, common.CompressionLevelBest); err != nil {
+		return err
+	} else if _, err := io.Copy(w, io.NewSectionReader(instance.f, int64(e.Offset), e.Length)); err != nil {
+		_ = w.Close()
+		return err
+	} else if err := w.Close(); err != nil {
+		return err
+	}
+	if int64(buf.Len()) >= e.Length {
+		return nil
+	}
+
+	fileMode, t := e.FileMode, e.Time
+	return instance.Write(TargetEntry{
+		Filename: target,
+		FileMode: &fileMode,
+		Time:     &t,
+	}, buf)
+}
@@ -0,0 +1,52 @@
+package packed
+
+import (
+	"bytes"
+	"github.com/echocat/goxr/common"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Writer_WritePrecompressed(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-precompress-")
+	assert.NoError(t, err)
+	defer deletePathForT(root, t)
+	html := strings.Repeat("<p>Hello world!</p>\n", 100)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html"), []byte(html), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "small.html"), []byte("<p>Hello world!</p>"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "image.png"), garbageBytes(1024), 0644))
+
+	fn := tempFileWithBytesOf()
+	defer deletePathForT(fn, t)
+	writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.WriteFilesRecursiveWithPrefix("", root, nil))
+	assert.NoError(t, writer.WritePrecompressed([]common.Encoding{common.EncodingBrotli, common.EncodingGzip}, nil))
+	assert.NoError(t, writer.Close())
+
+	box, err := OpenBox(fn)
+	assert.NoError(t, err)
+	defer closeForT(box, t)
+
+	assert.NotNil(t, box.Entries.Find("index.html.br"))
+	assert.Nil(t, box.Entries.Find("index.html.zst"))
+	assert.Nil(t, box.Entries.Find("small.html.gz"))
+	assert.Nil(t, box.Entries.Find("image.png.gz"))
+
+	f, err := box.Open("index.html.gz")
+	assert.NoError(t, err)
+	defer closeForT(f, t)
+	encoded, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.True(t, len(encoded) < len(html))
+
+	r, err := gzip.NewReader(bytes.NewReader(encoded))
+	assert.NoError(t, err)
+	decoded, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, html, string(decoded))
+}
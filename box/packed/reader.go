@@ -101,6 +101,11 @@ func readBox(filename string, from io.ReadSeeker, tocOffset common.FileOffset) (
 	} else if n < int64(tocOffset) {
 		return Box{}, common.NewPathError("readBox", filename, io.EOF)
 	}
+	return DecodeBox(filename, from)
+}
+
+// DecodeBox decodes the table of contents of a box from the given reader.
+func DecodeBox(filename string, from io.Reader) (Box, error) {
 	result := Box{}
 	decoder := msgpack.NewDecoder(from)
 	if err := decoder.Decode(&result); err != nil {
@@ -0,0 +1,249 @@
+package packed
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MetaLinkTarget is stored in the entry.Meta of every entry which was stored as link.
+	// It contains the path inside the box the link is pointing to.
+	MetaLinkTarget = "linkTarget"
+
+	maxLinkHops = 40
+)
+
+type SymlinkPolicy struct {
+	name   string
+	follow bool
+	link   bool
+}
+
+var (
+	SymlinkPolicyFollow = SymlinkPolicy{name: "follow", follow: true}
+	SymlinkPolicySkip   = SymlinkPolicy{name: "skip"}
+	SymlinkPolicyLink   = SymlinkPolicy{name: "link", link: true}
+
+	symlinkPolicies        = []SymlinkPolicy{SymlinkPolicyFollow, SymlinkPolicySkip, SymlinkPolicyLink}
+	lowerToSymlinkPolicies = func(policies []SymlinkPolicy) map[string]SymlinkPolicy {
+		result := make(map[string]SymlinkPolicy)
+		for _, policy := range policies {
+			result[strings.ToLower(policy.String())] = policy
+		}
+		return result
+	}(symlinkPolicies)
+)
+
+func SymlinkPolicies() []SymlinkPolicy {
+	return symlinkPolicies
+}
+
+func (instance *SymlinkPolicy) Set(in string) error {
+	if candidate, ok := lowerToSymlinkPolicies[strings.ToLower(in)]; ok {
+		*instance = candidate
+		return nil
+	}
+	return fmt.Errorf("illegal symlink policy '%s' - supported are: %v", in, symlinkPolicies)
+}
+
+func (instance *SymlinkPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain string
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	return instance.Set(plain)
+}
+
+func (instance SymlinkPolicy) MarshalYAML() (interface{}, error) {
+	return instance.String(), nil
+}
+
+func (instance SymlinkPolicy) IsFollow() bool {
+	return instance.follow
+}
+
+func (instance SymlinkPolicy) IsLink() bool {
+	return instance.link
+}
+
+func (instance SymlinkPolicy) IsSkip() bool {
+	return !instance.follow && !instance.link
+}
+
+func (instance SymlinkPolicy) IsZero() bool {
+	return instance.name == ""
+}
+
+func (instance SymlinkPolicy) String() string {
+	return instance.name
+}
+
+func (instance *Writer) WriteLink(te TargetEntry, linkTarget string) error {
+	mode := os.FileMode(0777)
+	if te.FileMode != nil {
+		mode = te.FileMode.Perm()
+	}
+	if te.Meta == nil {
+		te.Meta = make(entry.Meta)
+	}
+	te.FileMode = common.PosFileMode(mode | os.ModeSymlink)
+	te.Meta[MetaLinkTarget] = entry.CleanPath(linkTarget)
+	te.Fingerprint = false
+	te.Transformers = nil
+	return instance.Write(te, bytes.NewReader(nil))
+}
+
+type filesWalker struct {
+	writer      *Writer
+	roots       []string
+	prefix      string
+	interceptor WriteFilesInterceptor
+}
+
+func (instance *filesWalker) walk(sourceFilename string, relative string, fi os.FileInfo, ancestors []string) error {
+	if fi.Mode()&os.ModeSymlink != 0 && len(ancestors) > 0 {
+		policy := instance.writer.SymlinkPolicy
+		if policy.IsZero() {
+			policy = SymlinkPolicyFollow
+		}
+		if policy.IsSkip() {
+			return nil
+		} else if policy.IsLink() {
+			return instance.link(sourceFilename, relative, fi)
+		} else if target, err := os.Stat(sourceFilename); err != nil {
+			return err
+		} else {
+			fi = target
+		}
+	}
+	if !fi.IsDir() {
+		return instance.file(sourceFilename, relative, fi)
+	}
+
+	realPath, err := filepath.EvalSymlinks(sourceFilename)
+	if err != nil {
+		return err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == realPath {
+			return fmt.Errorf("%s: %v (points to %s)", sourceFilename, ErrSymlinkLoop, realPath)
+		}
+	}
+	children, err := ioutil.ReadDir(sourceFilename)
+	if err != nil {
+		return err
+	}
+	ancestors = append(ancestors, realPath)
+	for _, child := range children {
+		if err := instance.walk(filepath.Join(sourceFilename, child.Name()), path.Join(relative, child.Name()), child, ancestors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (instance *filesWalker) file(sourceFilename string, relative string, fi os.FileInfo) error {
+	if candidate, err := instance.candidateFor(sourceFilename, relative, fi); err != nil || candidate == nil {
+		return err
+	} else {
+		return instance.writer.WriteFile(candidate.SourceFilename, *candidate.Target)
+	}
+}
+
+func (instance *filesWalker) candidateFor(sourceFilename string, relative string, fi os.FileInfo) (*WriteCandidate, error) {
+	candidate := &WriteCandidate{
+		Accept:         true,
+		SourceFilename: sourceFilename,
+		SourceFileInfo: fi,
+		Target: &TargetEntry{
+			Filename: entry.CleanPath(path.Join(instance.prefix, relative)),
+			FileMode: common.PosFileMode(fi.Mode()),
+			Time:     common.PtimeTime(fi.ModTime()),
+			Meta:     make(entry.Meta),
+		},
+	}
+	if interceptor := instance.interceptor; interceptor != nil {
+		if err := interceptor(candidate); err != nil {
+			return nil, err
+		}
+	}
+	if !candidate.Accept {
+		return nil, nil
+	}
+	return candidate, nil
+}
+
+func (instance *filesWalker) link(sourceFilename string, relative string, fi os.FileInfo) error {
+	target, err := os.Readlink(sourceFilename)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(sourceFilename), target)
+	}
+	target = filepath.Clean(target)
+
+	relativeTarget := ""
+	for _, root := range instance.roots {
+		if candidate, err := filepath.Rel(root, target); err == nil && candidate != ".." && !strings.HasPrefix(candidate, ".."+string(filepath.Separator)) {
+			relativeTarget = filepath.ToSlash(candidate)
+			break
+		}
+	}
+	if relativeTarget == "" {
+		return fmt.Errorf("%s: symlink points to %s which is outside of %s and cannot be stored as link", sourceFilename, target, instance.roots[0])
+	}
+
+	if candidate, err := instance.candidateFor(sourceFilename, relative, fi); err != nil || candidate == nil {
+		return err
+	} else {
+		return instance.writer.WriteLink(*candidate.Target, path.Join(instance.prefix, relativeTarget))
+	}
+}
+
+func linkTargetOf(e *entry.Entry) (string, bool) {
+	if e.FileMode&os.ModeSymlink == 0 {
+		return "", false
+	}
+	target, ok := e.Meta[MetaLinkTarget].(string)
+	return target, ok
+}
+
+// resolveEntry finds the entry of the given candidate and resolves every link entry on
+// its way - including links of its parent directories.
+func (instance *Box) resolveEntry(candidate string) (*entry.Entry, string) {
+	for hops := 0; hops < maxLinkHops; hops++ {
+		if e := instance.Entries.Find(candidate); e != nil {
+			if target, ok := linkTargetOf(e); ok {
+				candidate = target
+				continue
+			}
+			return e, candidate
+		}
+		if resolved, ok := instance.resolveLinkedAncestor(candidate); ok {
+			candidate = resolved
+			continue
+		}
+		return nil, candidate
+	}
+	return nil, candidate
+}
+
+func (instance *Box) resolveLinkedAncestor(candidate string) (string, bool) {
+	for dir := path.Dir(candidate); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if e := instance.Entries.Find(dir); e != nil {
+			if target, ok := linkTargetOf(e); ok {
+				return path.Join(target, candidate[len(dir)+1:]), true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,111 @@
+package packed
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Writer_symlinks(t *testing.T) {
+	root := symlinksTestDirForT(t)
+	defer deletePathForT(root, t)
+
+	writeBoxWith := func(policy SymlinkPolicy) (*Box, error) {
+		fn := tempFileWithBytesOf()
+		defer deletePathForT(fn, t)
+		writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+		assert.NoError(t, err)
+		writer.SymlinkPolicy = policy
+		if err := writer.WriteFilesRecursiveWithPrefix("", root, nil); err != nil {
+			_ = writer.Close()
+			return nil, err
+		}
+		assert.NoError(t, writer.Close())
+		return OpenBox(fn)
+	}
+	contentOf := func(box *Box, pathname string) string {
+		f, err := box.Open(pathname)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer closeForT(f, t)
+		b, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("follow", func(t *testing.T) {
+		box, err := writeBoxWith(SymlinkPolicyFollow)
+		assert.NoError(t, err)
+		defer closeForT(box, t)
+
+		assert.Equal(t, "a", contentOf(box, "dir/a.txt"))
+		assert.Equal(t, "a", contentOf(box, "link.txt"))
+		assert.Equal(t, "a", contentOf(box, "linkedDir/a.txt"))
+		assert.Nil(t, box.Entries.Find("linkedDir"))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		box, err := writeBoxWith(SymlinkPolicySkip)
+		assert.NoError(t, err)
+		defer closeForT(box, t)
+
+		assert.Equal(t, "a", contentOf(box, "dir/a.txt"))
+		assert.Nil(t, box.Entries.Find("link.txt"))
+		assert.Nil(t, box.Entries.Find("linkedDir/a.txt"))
+	})
+
+	t.Run("link", func(t *testing.T) {
+		box, err := writeBoxWith(SymlinkPolicyLink)
+		assert.NoError(t, err)
+		defer closeForT(box, t)
+
+		assert.Equal(t, "a", contentOf(box, "link.txt"))
+		assert.Equal(t, "a", contentOf(box, "linkedDir/a.txt"))
+		assert.Nil(t, box.Entries.Find("linkedDir/a.txt"))
+		assert.Equal(t, "dir/a.txt", box.Entries.Find("link.txt").Meta[MetaLinkTarget])
+
+		fi, err := box.Info("linkedDir/a.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), fi.Size())
+	})
+
+	t.Run("loop", func(t *testing.T) {
+		assert.NoError(t, os.Symlink("..", filepath.Join(root, "dir", "loop")))
+		defer deletePathForT(filepath.Join(root, "dir", "loop"), t)
+
+		_, err := writeBoxWith(SymlinkPolicyFollow)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), ErrSymlinkLoop.Error())
+	})
+
+	t.Run("linkOutsideOfRoot", func(t *testing.T) {
+		outside := tempFileWithBytesOf()
+		defer deletePathForT(outside, t)
+		assert.NoError(t, os.Symlink(outside, filepath.Join(root, "outside")))
+		defer deletePathForT(filepath.Join(root, "outside"), t)
+
+		_, err := writeBoxWith(SymlinkPolicyLink)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "outside of")
+	})
+}
+
+func Test_SymlinkPolicy_Set(t *testing.T) {
+	var actual SymlinkPolicy
+	assert.NoError(t, actual.Set("LINK"))
+	assert.Equal(t, SymlinkPolicyLink, actual)
+	assert.EqualError(t, actual.Set("foo"), "illegal symlink policy 'foo' - supported are: [follow skip link]")
+}
+
+func symlinksTestDirForT(t *testing.T) string {
+	root, err := ioutil.TempDir("", "goxr-symlinks-")
+	assert.NoError(t, err)
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "dir"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "dir", "a.txt"), []byte("a"), 0644))
+	assert.NoError(t, os.Symlink(filepath.Join("dir", "a.txt"), filepath.Join(root, "link.txt")))
+	assert.NoError(t, os.Symlink("dir", filepath.Join(root, "linkedDir")))
+	return root
+}
@@ -0,0 +1,66 @@
+package packed
+
+import (
+	"bytes"
+	"github.com/echocat/goxr/common"
+	"hash/crc64"
+	"io"
+)
+
+// TrailerPrefix marks the trailer which is written at the very end of every
+// box. It points to the offset of the header and allows to locate the box
+// without scanning the whole file (for example over HTTP range requests).
+// Readers which are not aware of the trailer simply ignore it.
+const TrailerPrefix = "goxr.trl"
+
+var (
+	trailerPrefix             = []byte(TrailerPrefix)
+	trailerHeaderOffsetLength = 8
+	trailerChecksumLength     = crc64.Size
+	trailerLength             = len(trailerPrefix) + trailerHeaderOffsetLength + trailerChecksumLength
+)
+
+// WriteTrailer writes the trailer pointing to the header at the given offset.
+func WriteTrailer(headerOffset common.FileOffset, to io.Writer) error {
+	checksumBytes := common.Crc64Of(trailerPrefix, uint64(headerOffset))
+	return common.Write(common.ConcatBytes(trailerPrefix, uint64(headerOffset), checksumBytes), to)
+}
+
+// ParseTrailer returns the offset of the header of the box if the given
+// bytes are a valid trailer.
+func ParseTrailer(candidate []byte) (headerOffset common.FileOffset, ok bool) {
+	if len(candidate) != trailerLength || !bytes.HasPrefix(candidate, trailerPrefix) {
+		return 0, false
+	}
+	offsetBytes := candidate[len(trailerPrefix) : len(trailerPrefix)+trailerHeaderOffsetLength]
+	if !bytes.Equal(candidate[len(trailerPrefix)+trailerHeaderOffsetLength:], common.Crc64Of(trailerPrefix, offsetBytes)) {
+		return 0, false
+	}
+	return common.FileOffset(common.BytesToUint64(offsetBytes)), true
+}
+
+// ParseHeader returns the header if the given bytes are a valid header which
+// is located at the given offset.
+func ParseHeader(candidate []byte, offset common.FileOffset) (*Header, error) {
+	if version, tocOffset, err := checkHeaderCandidate(candidate); err != nil {
+		return nil, err
+	} else if version == nil {
+		return nil, common.ErrDoesNotContainBox
+	} else {
+		return &Header{
+			Version:   *version,
+			Offset:    offset,
+			TocOffset: tocOffset,
+		}, nil
+	}
+}
+
+// HeaderLength is the length of the header of every box.
+func HeaderLength() int {
+	return headerLength
+}
+
+// TrailerLength is the length of the trailer of every box.
+func TrailerLength() int {
+	return trailerLength
+}
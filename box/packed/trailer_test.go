@@ -0,0 +1,26 @@
+package packed
+
+import (
+	"bytes"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Trailer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteTrailer(common.FileOffset(666), buf))
+	assert.Equal(t, TrailerLength(), buf.Len())
+
+	offset, ok := ParseTrailer(buf.Bytes())
+	assert.True(t, ok)
+	assert.Equal(t, common.FileOffset(666), offset)
+
+	broken := buf.Bytes()
+	broken[len(broken)-1]++
+	_, ok = ParseTrailer(broken)
+	assert.False(t, ok)
+
+	_, ok = ParseTrailer(buf.Bytes()[1:])
+	assert.False(t, ok)
+}
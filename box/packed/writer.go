@@ -9,6 +9,7 @@ import (
 	_ "github.com/vmihailenco/msgpack"
 	"hash"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -16,60 +17,116 @@ import (
 	"time"
 )
 
-func NewWriter(filename string, om OpenMode, wm WriteMode) (writer *Writer, rErr error) {
-	of := os.O_RDWR
-	if om.IsCreate() {
-		of |= os.O_CREATE
-	}
-	if !om.IsOpen() {
-		of |= os.O_EXCL
-	}
-	if f, err := os.OpenFile(filename, of, 0644); err != nil {
+// NewWriter creates a writer for a box at the given file. The box is written
+// into a temporary file next to it which replaces the file not before Close
+// succeeds; so a failed or aborted build leaves the file untouched.
+func NewWriter(filename string, om OpenMode, wm WriteMode) (*Writer, error) {
+	return NewWriterBasedOn(filename, filename, om, wm)
+}
+
+// NewWriterBasedOn works like NewWriter but the content in front of the box
+// (for example an executable) is taken from the given base file. The OpenMode
+// applies to filename and the WriteMode to base.
+func NewWriterBasedOn(base string, filename string, om OpenMode, wm WriteMode) (writer *Writer, rErr error) {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(filename); os.IsNotExist(err) {
+		if !om.IsCreate() {
+			return nil, common.NewPathError("newWriter", filename, err)
+		}
+	} else if err != nil {
 		return nil, common.NewPathError("newWriter", filename, err)
+	} else if !om.IsOpen() {
+		return nil, common.NewPathError("newWriter", filename, os.ErrExist)
 	} else {
-		success := false
-		defer func() {
-			if !success {
-				if dErr := f.Close(); dErr != nil {
-					rErr = dErr
-				}
-			}
-		}()
+		mode = fi.Mode().Perm()
+	}
 
-		if header, err := FindHeader(f); err != nil {
-			return nil, common.NewPathError("newWriter", filename, err)
-		} else if header != nil {
-			if !wm.IsReplace() {
-				return nil, common.NewPathError("newWriter", filename, common.ErrDoesContainBox)
-			} else if err := f.Truncate(int64(header.Offset)); err != nil {
-				return nil, common.NewPathError("newWriter", filename, err)
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return nil, common.NewPathError("newWriter", filename, err)
+	}
+	success := false
+	defer func() {
+		if !success {
+			if dErr := f.Close(); dErr != nil {
+				rErr = dErr
 			}
-		} else if !wm.IsNew() {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if fi, err := os.Stat(base); os.IsNotExist(err) && base == filename {
+		if !wm.IsNew() {
 			return nil, common.NewPathError("newWriter", filename, common.ErrDoesNotContainBox)
 		}
+	} else if err != nil {
+		return nil, common.NewPathError("newWriter", base, err)
+	} else if err := copyContentInFrontOfBox(base, f, wm); err != nil {
+		return nil, err
+	} else if base != filename {
+		mode = fi.Mode().Perm()
+	}
 
-		if fi, err := f.Stat(); err != nil {
-			return nil, common.NewPathError("newWriter", filename, err)
-		} else if err := common.Seek(common.FileOffset(fi.Size()), f); err != nil {
-			return nil, common.NewPathError("newWriter", filename, err)
-		} else if err := WriteHeader(Version(1), 0, f); err != nil {
-			return nil, common.NewPathError("newWriter", filename, err)
-		} else {
-			writer = &Writer{
-				f:            f,
-				filename:     filename,
-				headerOffset: common.FileOffset(fi.Size()),
-				offset:       common.FileOffset(fi.Size()) + common.FileOffset(headerLength),
-				box: Box{
-					Built: time.Now(),
-				},
-			}
-			success = true
-			return writer, nil
+	if err := f.Chmod(mode); err != nil {
+		return nil, common.NewPathError("newWriter", filename, err)
+	} else if fi, err := f.Stat(); err != nil {
+		return nil, common.NewPathError("newWriter", filename, err)
+	} else if err := WriteHeader(Version(1), 0, f); err != nil {
+		return nil, common.NewPathError("newWriter", filename, err)
+	} else {
+		writer = &Writer{
+			f:            f,
+			filename:     filename,
+			headerOffset: common.FileOffset(fi.Size()),
+			offset:       common.FileOffset(fi.Size()) + common.FileOffset(headerLength),
+			box: Box{
+				Built: time.Now(),
+			},
+			AssetManifestFilename: AssetManifestFilename,
+			SymlinkPolicy:         SymlinkPolicyFollow,
 		}
+		success = true
+		return writer, nil
 	}
 }
 
+// copyContentInFrontOfBox copies the content of the given file without a
+// contained box to the given writer.
+func copyContentInFrontOfBox(filename string, to io.Writer, wm WriteMode) (rErr error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return common.NewPathError("newWriter", filename, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = err
+		}
+	}()
+
+	var length int64
+	if header, err := FindHeader(f); err != nil {
+		return common.NewPathError("newWriter", filename, err)
+	} else if header != nil {
+		if !wm.IsReplace() {
+			return common.NewPathError("newWriter", filename, common.ErrDoesContainBox)
+		}
+		length = int64(header.Offset)
+	} else if !wm.IsNew() {
+		return common.NewPathError("newWriter", filename, common.ErrDoesNotContainBox)
+	} else if fi, err := f.Stat(); err != nil {
+		return common.NewPathError("newWriter", filename, err)
+	} else {
+		length = fi.Size()
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return common.NewPathError("newWriter", filename, err)
+	} else if _, err := io.CopyN(to, f, length); err != nil {
+		return common.NewPathError("newWriter", filename, err)
+	}
+	return nil
+}
+
 type Writer struct {
 	f            *os.File
 	filename     string
@@ -79,13 +136,21 @@ type Writer struct {
 	activeEntryWriter *entryWriter
 	box               Box
 	closed            bool
+
+	AssetManifestFilename string
+	SymlinkPolicy         SymlinkPolicy
+	// PolicyChecker records the size of every written entry if set.
+	PolicyChecker *PolicyChecker
+	fingerprints  map[string]string
 }
 
 type TargetEntry struct {
-	Filename string
-	FileMode *os.FileMode
-	Time     *time.Time
-	Meta     entry.Meta
+	Filename     string
+	FileMode     *os.FileMode
+	Time         *time.Time
+	Meta         entry.Meta
+	Fingerprint  bool
+	Transformers []ContentTransformer
 }
 
 func (instance *Writer) NewWriter(te TargetEntry) (io.WriteCloser, error) {
@@ -133,6 +198,13 @@ func (instance *Writer) Box() *Box {
 }
 
 func (instance *Writer) Write(te TargetEntry, source io.Reader) (rErr error) {
+	for _, transformer := range te.Transformers {
+		if transformed, err := transformer.TransformContent(te, source); err != nil {
+			return common.NewPathError("transformEntry", te.Filename, err)
+		} else {
+			source = transformed
+		}
+	}
 	if writer, err := instance.NewWriter(te); err != nil {
 		return err
 	} else {
@@ -184,48 +256,38 @@ func (instance *Writer) WriteFilesRecursive(root string, interceptor WriteFilesI
 	parts := strings.SplitN(root, "=", 2)
 	prefix := ""
 	if len(parts) > 1 {
-		prefix = path.Clean(filepath.ToSlash(parts[0]))
+		prefix = parts[0]
+		root = parts[1]
+	}
+	return instance.WriteFilesRecursiveWithPrefix(prefix, root, interceptor)
+}
+
+func (instance *Writer) WriteFilesRecursiveWithPrefix(prefix string, root string, interceptor WriteFilesInterceptor) error {
+	if prefix != "" {
+		prefix = path.Clean(filepath.ToSlash(prefix))
 		if prefix != "" {
 			prefix += "/"
 		}
-		root = parts[1]
 	}
 
-	if absRoot, err := filepath.Abs(root); err != nil {
-		return err
-	} else if err := filepath.Walk(absRoot, func(sourceFilename string, sourceFileInfo os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		} else if sourceFileInfo.IsDir() {
-			return nil
-		} else if sourceFilename, err := filepath.Abs(sourceFilename); err != nil {
-			return err
-		} else if relativeSourceFilename, err := filepath.Rel(absRoot, sourceFilename); err != nil {
-			return err
-		} else {
-			candidate := WriteCandidate{
-				Accept:         true,
-				SourceFilename: sourceFilename,
-				SourceFileInfo: sourceFileInfo,
-				Target: &TargetEntry{
-					Filename: entry.CleanPath(path.Join(prefix, filepath.ToSlash(relativeSourceFilename))),
-					FileMode: common.PosFileMode(sourceFileInfo.Mode()),
-					Time:     common.PtimeTime(sourceFileInfo.ModTime()),
-					Meta:     make(entry.Meta),
-				},
-			}
-			if interceptor != nil {
-				if err := interceptor(&candidate); err != nil {
-					return err
-				}
-			}
-			if !candidate.Accept {
-				return nil
-			} else {
-				return instance.WriteFile(candidate.SourceFilename, *candidate.Target)
-			}
-		}
-	}); err != nil {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return common.NewPathError("writeFilesRecursive", root, err)
+	}
+	walker := &filesWalker{
+		writer:      instance,
+		roots:       []string{absRoot},
+		prefix:      prefix,
+		interceptor: interceptor,
+	}
+	if realRoot, err := filepath.EvalSymlinks(absRoot); err != nil {
+		return common.NewPathError("writeFilesRecursive", root, err)
+	} else if realRoot != absRoot {
+		walker.roots = append(walker.roots, realRoot)
+	}
+	if fi, err := os.Stat(absRoot); err != nil {
+		return common.NewPathError("writeFilesRecursive", root, err)
+	} else if err := walker.walk(absRoot, "", fi, nil); err != nil {
 		return common.NewPathError("writeFilesRecursive", root, err)
 	}
 	return nil
@@ -234,6 +296,8 @@ func (instance *Writer) WriteFilesRecursive(root string, interceptor WriteFilesI
 func (instance *Writer) writeBox() error {
 	if err := msgpack.NewEncoder(instance.f).Encode(instance.box); err != nil {
 		return common.NewPathError("writeBox", instance.filename, err)
+	} else if err := WriteTrailer(instance.headerOffset, instance.f); err != nil {
+		return common.NewPathError("writeBox", instance.filename, err)
 	} else if err := common.Seek(instance.headerOffset, instance.f); err != nil {
 		return common.NewPathError("writeBox", instance.filename, err)
 	} else if err := WriteHeader(Version(1), instance.offset, instance.f); err != nil {
@@ -243,16 +307,38 @@ func (instance *Writer) writeBox() error {
 	}
 }
 
+// Close writes the box and replaces the target file with it.
 func (instance *Writer) Close() (rErr error) {
 	defer func() {
-		if dErr := instance.f.Close(); dErr != nil {
+		if dErr := instance.f.Close(); dErr != nil && rErr == nil {
 			rErr = dErr
 		}
+		if rErr != nil {
+			_ = os.Remove(instance.f.Name())
+		} else if err := os.Rename(instance.f.Name(), instance.filename); err != nil {
+			_ = os.Remove(instance.f.Name())
+			rErr = common.NewPathError("close", instance.filename, err)
+		}
 	}()
 
+	if err := instance.writeAssetManifest(); err != nil {
+		return err
+	}
 	return instance.writeBox()
 }
 
+// Abort closes the writer without writing the box. The target file stays
+// untouched; only the temporary file is removed.
+func (instance *Writer) Abort() error {
+	if err := instance.f.Close(); err != nil {
+		_ = os.Remove(instance.f.Name())
+		return common.NewPathError("abort", instance.filename, err)
+	} else if err := os.Remove(instance.f.Name()); err != nil {
+		return common.NewPathError("abort", instance.filename, err)
+	}
+	return nil
+}
+
 type entryWriter struct {
 	parent      *Writer
 	targetEntry TargetEntry
@@ -287,6 +373,13 @@ func (instance *entryWriter) Close() error {
 	}
 	instance.closed = true
 
+	// De-attach first, otherwise a failure below would block the Writer forever.
+	if instance.parent.activeEntryWriter != instance {
+		return common.NewPathError("close", instance.targetEntry.Filename, errors.New("entryWriter is already de-attached from Writer"))
+	}
+	instance.parent.offset += common.FileOffset(instance.written)
+	instance.parent.activeEntryWriter = nil
+
 	hashArray := entry.Sha256Checksum{}
 	copy(hashArray[:], instance.hash.Sum(nil))
 
@@ -298,12 +391,14 @@ func (instance *entryWriter) Close() error {
 		if err := instance.parent.box.Entries.Replace(instance.targetEntry.Filename, e); err != nil {
 			return common.NewPathError("close", instance.targetEntry.Filename, err)
 		}
+		if instance.targetEntry.Fingerprint {
+			if err := instance.parent.fingerprint(e); err != nil {
+				return common.NewPathError("close", instance.targetEntry.Filename, err)
+			}
+		}
+		if checker := instance.parent.PolicyChecker; checker != nil {
+			checker.Record(e.Filename, common.FileSize(instance.written))
+		}
 	}
-
-	if instance.parent.activeEntryWriter != instance {
-		return common.NewPathError("close", instance.targetEntry.Filename, errors.New("entryWriter is already de-attached from Writer"))
-	}
-	instance.parent.offset += common.FileOffset(instance.written)
-	instance.parent.activeEntryWriter = nil
 	return nil
 }
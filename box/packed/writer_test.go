@@ -0,0 +1,76 @@
+package packed
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Writer_Abort(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goxr-abort-")
+	assert.NoError(t, err)
+	defer deletePathForT(dir, t)
+	fn := filepath.Join(dir, "test.box")
+
+	t.Run("created", func(t *testing.T) {
+		writer, err := NewWriter(fn, OpenModeCreateOnly, WriteModeNewOnly)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(TargetEntry{Filename: "a.txt"}, strings.NewReader("a")))
+		assert.NoError(t, writer.Abort())
+
+		_, err = os.Stat(fn)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(fn, []byte("executable"), 0755))
+		writer, err := NewWriter(fn, OpenModeOpenOnly, WriteModeNewOnly)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(TargetEntry{Filename: "a.txt"}, strings.NewReader("a")))
+		assert.NoError(t, writer.Close())
+
+		writer, err = NewWriter(fn, OpenModeOpenOnly, WriteModeReplaceOnly)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(TargetEntry{Filename: "b.txt"}, strings.NewReader("b")))
+		assert.NoError(t, writer.Abort())
+
+		box, err := OpenBox(fn)
+		assert.NoError(t, err)
+		defer closeForT(box, t)
+		assert.NotNil(t, box.Entries.Find("a.txt"))
+		assert.Nil(t, box.Entries.Find("b.txt"))
+	})
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1, "temporary files are left behind")
+}
+
+func Test_NewWriterBasedOn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goxr-based-on-")
+	assert.NoError(t, err)
+	defer deletePathForT(dir, t)
+	base := filepath.Join(dir, "server")
+	fn := filepath.Join(dir, "test")
+	assert.NoError(t, ioutil.WriteFile(base, []byte("executable"), 0755))
+	assert.NoError(t, ioutil.WriteFile(fn, []byte("existing"), 0644))
+
+	_, err = NewWriterBasedOn(base, fn, OpenModeCreateOnly, WriteModeNewOnly)
+	assert.True(t, os.IsExist(err))
+
+	writer, err := NewWriterBasedOn(base, fn, OpenModeOpenOrCreate, WriteModeNewOnly)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(TargetEntry{Filename: "a.txt"}, strings.NewReader("a")))
+	assert.NoError(t, writer.Close())
+
+	b, err := ioutil.ReadFile(fn)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(b), "executable"))
+	fi, err := os.Stat(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+	assert.Equal(t, int64(len("executable")), fileSizeForT(base, t))
+}
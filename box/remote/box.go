@@ -0,0 +1,227 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrRangeNotSupported = errors.New("server does not support range requests")
+	ErrNoTrailer         = errors.New("box does not contain a trailer; it has to be created with a newer version of goxr")
+	ErrChecksumMismatch  = errors.New("checksum of received entry does not match")
+)
+
+// DefaultCacheDirectory returns the directory where entries of remote boxes
+// are cached if no other one is configured.
+func DefaultCacheDirectory() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "goxr", "remote")
+	}
+	return filepath.Join(os.TempDir(), "goxr", "remote")
+}
+
+// Open opens the box located at the given URL using the default Opener.
+func Open(url string) (*packed.Box, error) {
+	return Opener{}.Open(url)
+}
+
+// Opener opens packed boxes which are located on HTTP servers. Only header,
+// trailer and the table of contents are fetched while opening. The content of
+// the entries is fetched lazily using range requests on first access and
+// stored in CacheDirectory.
+type Opener struct {
+	// Client which is used for all requests. If nil http.DefaultClient is used.
+	Client *http.Client
+	// CacheDirectory where the fetched entries are stored. If empty
+	// DefaultCacheDirectory() is used.
+	CacheDirectory string
+}
+
+func (instance Opener) Open(url string) (*packed.Box, error) {
+	r := &reader{
+		url:            url,
+		client:         instance.Client,
+		cacheDirectory: instance.CacheDirectory,
+	}
+	if r.client == nil {
+		r.client = http.DefaultClient
+	}
+	if r.cacheDirectory == "" {
+		r.cacheDirectory = DefaultCacheDirectory()
+	}
+
+	trailer, size, err := r.fetchSuffix(int64(packed.TrailerLength()))
+	if err != nil {
+		return nil, common.NewPathError("openBox", url, err)
+	}
+	headerOffset, ok := packed.ParseTrailer(trailer)
+	if !ok {
+		return nil, common.NewPathError("openBox", url, ErrNoTrailer)
+	}
+	headerBytes, err := r.fetchRange(int64(headerOffset), int64(packed.HeaderLength()))
+	if err != nil {
+		return nil, common.NewPathError("openBox", url, err)
+	}
+	header, err := packed.ParseHeader(headerBytes, headerOffset)
+	if err != nil {
+		return nil, common.NewPathError("openBox", url, err)
+	}
+	tocLength := size - int64(packed.TrailerLength()) - int64(header.TocOffset)
+	if tocLength <= 0 {
+		return nil, common.NewPathError("openBox", url, common.ErrDoesNotContainBox)
+	}
+	toc, err := r.fetchRange(int64(header.TocOffset), tocLength)
+	if err != nil {
+		return nil, common.NewPathError("openBox", url, err)
+	}
+	box, err := packed.DecodeBox(url, bytes.NewReader(toc))
+	if err != nil {
+		return nil, err
+	}
+	box.EntryToFileTransformer = packed.ToFileTransformerFor(r.newEntryReader)
+	return &box, nil
+}
+
+type reader struct {
+	url            string
+	client         *http.Client
+	cacheDirectory string
+}
+
+func (instance *reader) newEntryReader(e *entry.Entry) (entry.Reader, error) {
+	if e.Length == 0 {
+		return bytes.NewReader(nil), nil
+	}
+	cacheFilename := filepath.Join(instance.cacheDirectory, hex.EncodeToString(e.Checksum[:]))
+	if f, err := openCacheFile(cacheFilename, e.Length); err == nil {
+		return f, nil
+	}
+
+	if err := instance.fetchToCacheFile(e, cacheFilename); err != nil {
+		return nil, err
+	}
+	return openCacheFile(cacheFilename, e.Length)
+}
+
+// openCacheFile opens the given cache file if it has the expected length. The
+// checksum is not verified again because cache files are only written (see
+// fetchToCacheFile) after their checksum was verified.
+func openCacheFile(filename string, expectedLength int64) (*os.File, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := f.Stat(); err != nil {
+		_ = f.Close()
+		return nil, err
+	} else if fi.Size() != expectedLength {
+		_ = f.Close()
+		return nil, fmt.Errorf("expected %d bytes of cache file %s but it has %d", expectedLength, filename, fi.Size())
+	}
+	return f, nil
+}
+
+// fetchToCacheFile streams the content of the given entry into a temporary
+// file which is moved to the given filename if its checksum matches.
+func (instance *reader) fetchToCacheFile(e *entry.Entry, filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	_, wErr := instance.fetch(fmt.Sprintf("bytes=%d-%d", e.Offset, int64(e.Offset)+e.Length-1), e.Length, io.MultiWriter(f, hash))
+	if cErr := f.Close(); wErr == nil {
+		wErr = cErr
+	}
+	if wErr == nil && !bytes.Equal(hash.Sum(nil), e.Checksum[:]) {
+		wErr = ErrChecksumMismatch
+	}
+	if wErr == nil {
+		wErr = os.Rename(f.Name(), filename)
+	}
+	if wErr != nil {
+		_ = os.Remove(f.Name())
+	}
+	return wErr
+}
+
+func (instance *reader) fetchRange(offset int64, length int64) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if _, err := instance.fetch(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1), length, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (instance *reader) fetchSuffix(length int64) ([]byte, int64, error) {
+	buf := new(bytes.Buffer)
+	size, err := instance.fetch(fmt.Sprintf("bytes=-%d", length), length, buf)
+	if err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), size, nil
+}
+
+// fetch requests the given range, writes its content to the given writer and
+// returns the total size of the remote file.
+func (instance *reader) fetch(byteRange string, expectedLength int64, to io.Writer) (size int64, rErr error) {
+	req, err := http.NewRequest(http.MethodGet, instance.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", byteRange)
+	resp, err := instance.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil && rErr == nil {
+			rErr = err
+		}
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, os.ErrNotExist
+	} else if resp.StatusCode == http.StatusOK {
+		return 0, ErrRangeNotSupported
+	} else if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("unexpected response status of %s (range %s): %s", instance.url, byteRange, resp.Status)
+	}
+	if size, err = parseContentRangeSize(resp.Header.Get("Content-Range")); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(to, io.LimitReader(resp.Body, expectedLength+1))
+	if err != nil {
+		return 0, err
+	} else if n != expectedLength {
+		return 0, fmt.Errorf("expected %d bytes of %s (range %s) but got %d", expectedLength, instance.url, byteRange, n)
+	}
+	return size, nil
+}
+
+func parseContentRangeSize(contentRange string) (int64, error) {
+	i := strings.LastIndexByte(contentRange, '/')
+	if i < 0 || !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, fmt.Errorf("illegal Content-Range: %s", contentRange)
+	}
+	if size, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err != nil {
+		return 0, fmt.Errorf("illegal Content-Range: %s", contentRange)
+	} else {
+		return size, nil
+	}
+}
@@ -0,0 +1,184 @@
+package remote
+
+import (
+	"encoding/hex"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Opener_Open(t *testing.T) {
+	boxFilename := packedBoxForT(t, map[string]string{
+		"a.txt":     "content of a",
+		"foo/b.txt": "content of b",
+		"empty.txt": "",
+	})
+	defer removeAllForT(boxFilename, t)
+	cacheDirectory, err := ioutil.TempDir("", "goxr-remote-cache-test")
+	assert.NoError(t, err)
+	defer removeAllForT(cacheDirectory, t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(resp, req, boxFilename)
+	}))
+	defer server.Close()
+
+	box, err := Opener{
+		Client:         server.Client(),
+		CacheDirectory: cacheDirectory,
+	}.Open(server.URL + "/assets.box")
+	assert.NoError(t, err)
+	defer closeForT(box, t)
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	assert.Equal(t, "content of a", contentOfForT(box, "a.txt", t))
+	assert.Equal(t, "content of b", contentOfForT(box, "foo/b.txt", t))
+	assert.Equal(t, "", contentOfForT(box, "empty.txt", t))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+
+	e := box.Entries.Find("a.txt")
+	assert.NotNil(t, e)
+	cached, err := ioutil.ReadFile(filepath.Join(cacheDirectory, hex.EncodeToString(e.Checksum[:])))
+	assert.NoError(t, err)
+	assert.Equal(t, "content of a", string(cached))
+
+	assert.Equal(t, "content of a", contentOfForT(box, "a.txt", t))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&requests))
+
+	_, err = box.Open("missing.txt")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_reader_newEntryReader(t *testing.T) {
+	boxFilename := packedBoxForT(t, map[string]string{
+		"a.txt": "content of a",
+	})
+	defer removeAllForT(boxFilename, t)
+	cacheDirectory, err := ioutil.TempDir("", "goxr-remote-cache-test")
+	assert.NoError(t, err)
+	defer removeAllForT(cacheDirectory, t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeFile(resp, req, boxFilename)
+	}))
+	defer server.Close()
+
+	box, err := Opener{
+		Client:         server.Client(),
+		CacheDirectory: cacheDirectory,
+	}.Open(server.URL + "/assets.box")
+	assert.NoError(t, err)
+	defer closeForT(box, t)
+	r := &reader{url: server.URL + "/assets.box", client: server.Client(), cacheDirectory: cacheDirectory}
+	e := *box.Entries.Find("a.txt")
+
+	t.Run("fetchAndCache", func(t *testing.T) {
+		before := atomic.LoadInt32(&requests)
+		er, err := r.newEntryReader(&e)
+		assert.NoError(t, err)
+		assert.IsType(t, &os.File{}, er)
+		assert.NoError(t, er.(*os.File).Close())
+		assert.Equal(t, before+1, atomic.LoadInt32(&requests))
+
+		er, err = r.newEntryReader(&e)
+		assert.NoError(t, err)
+		assert.IsType(t, &os.File{}, er)
+		_, err = er.Seek(11, io.SeekStart)
+		assert.NoError(t, err)
+		b, err := ioutil.ReadAll(er)
+		assert.NoError(t, err)
+		assert.Equal(t, "a", string(b))
+		assert.NoError(t, er.(*os.File).Close())
+		assert.Equal(t, before+1, atomic.LoadInt32(&requests))
+	})
+
+	t.Run("checksumMismatch", func(t *testing.T) {
+		tampered := e
+		tampered.Checksum[0] ^= 0xff
+		_, err := r.newEntryReader(&tampered)
+		assert.Equal(t, ErrChecksumMismatch, err)
+		files, err := ioutil.ReadDir(cacheDirectory)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	})
+}
+
+func Test_Opener_Open_rangeNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		_, _ = resp.Write([]byte("no ranges here"))
+	}))
+	defer server.Close()
+
+	_, err := Opener{Client: server.Client()}.Open(server.URL + "/assets.box")
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), ErrRangeNotSupported.Error()))
+}
+
+func Test_Opener_Open_notFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := Opener{Client: server.Client()}.Open(server.URL + "/assets.box")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_parseContentRangeSize(t *testing.T) {
+	size, err := parseContentRangeSize("bytes 10-19/1234")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234), size)
+
+	_, err = parseContentRangeSize("bytes 10-19/*")
+	assert.Error(t, err)
+	_, err = parseContentRangeSize("")
+	assert.Error(t, err)
+}
+
+func packedBoxForT(t *testing.T, files map[string]string) string {
+	f, err := ioutil.TempFile("", "goxr-remote-test.*.box")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	writer, err := packed.NewWriter(f.Name(), packed.OpenModeOpenOnly, packed.WriteModeNewOnly)
+	assert.NoError(t, err)
+	now := time.Now()
+	for name, content := range files {
+		assert.NoError(t, writer.Write(packed.TargetEntry{
+			Filename: name,
+			Time:     &now,
+		}, strings.NewReader(content)))
+	}
+	assert.NoError(t, writer.Close())
+	return f.Name()
+}
+
+func contentOfForT(box *packed.Box, name string, t *testing.T) string {
+	f, err := box.Open(name)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer closeForT(f, t)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func closeForT(what interface{ Close() error }, t *testing.T) {
+	assert.NoError(t, what.Close())
+}
+
+func removeAllForT(path string, t *testing.T) {
+	assert.NoError(t, os.RemoveAll(path))
+}
@@ -0,0 +1,121 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_OpenBoxBy_withOverlay(t *testing.T) {
+	baseDir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "base a",
+		"b.txt": "base b",
+	})
+	defer removeAllForT(baseDir, t)
+	overlay1 := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "overlay1 a",
+	})
+	defer removeAllForT(overlay1, t)
+	overlay2 := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "overlay2 a",
+		"b.txt": "overlay2 b",
+	})
+	defer removeAllForT(overlay2, t)
+	noBox := filepath.Join(baseDir, "a.txt")
+
+	assert.NoError(t, os.Setenv(OverlayEnv, overlay1+string(os.PathListSeparator)+overlay2))
+	defer func() {
+		assert.NoError(t, os.Unsetenv(OverlayEnv))
+	}()
+
+	contentOf := func(box Box, name string) string {
+		f, err := box.Open(name)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer func() {
+			assert.NoError(t, f.Close())
+		}()
+		b, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		box, err := OpenBoxBy(noBox, baseDir)
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, box.Close())
+		}()
+		assert.Equal(t, "overlay1 a", contentOf(box, "a.txt"))
+		assert.Equal(t, "overlay2 b", contentOf(box, "b.txt"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		AllowFallbackToFsBox = false
+		OnFallbackToFsBox = nil
+		defer func() {
+			AllowFallbackToFsBox = true
+			OnFallbackToFsBox = OnFallbackToFsBox_Default
+		}()
+
+		box, err := OpenBoxBy(noBox, baseDir)
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, box.Close())
+		}()
+		assert.Equal(t, "base a", contentOf(box, "a.txt"))
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		OnOverlay = OnOverlay_Fail
+		defer func() {
+			OnOverlay = OnOverlay_Default
+		}()
+
+		_, err := OpenBoxBy(noBox, baseDir)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), OverlayEnv)
+	})
+}
+
+func Test_OpenBoxBy_missingBase(t *testing.T) {
+	baseDir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "base a",
+	})
+	defer removeAllForT(baseDir, t)
+	noBox := filepath.Join(baseDir, "a.txt")
+
+	t.Run("fallbackRefused", func(t *testing.T) {
+		AllowFallbackToFsBox = false
+		defer func() {
+			AllowFallbackToFsBox = true
+		}()
+
+		_, err := OpenBoxBy(noBox, "goxr-missing-base")
+		assert.True(t, common.IsDoesNotContainBox(err), "expected does not contain box but got: %v", err)
+	})
+
+	t.Run("hookCalled", func(t *testing.T) {
+		var calledWith []string
+		OnFallbackToFsBox = func(_ string, bases []string, _ Box) error {
+			calledWith = bases
+			return nil
+		}
+		defer func() {
+			OnFallbackToFsBox = OnFallbackToFsBox_Default
+		}()
+
+		box, err := OpenBoxBy(noBox, "goxr-missing-base")
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, box.Close())
+		}()
+		assert.Equal(t, []string{"goxr-missing-base"}, calledWith)
+		_, err = box.Open("a.txt")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
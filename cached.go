@@ -0,0 +1,219 @@
+package goxr
+
+import (
+	"bytes"
+	"container/list"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheMaxEntrySize = common.FileSize(1024 * 1024)
+	DefaultCacheMaxTotalSize = common.FileSize(64 * 1024 * 1024)
+)
+
+// CachedBox keeps the content of small files of the wrapped box in memory.
+// Files bigger than MaxEntrySize are never cached. If the total size of all
+// cached files exceeds MaxTotalSize the least recently used ones are evicted.
+// Cached files are invalidated if modification time, size or checksum of the
+// file of the wrapped box changes.
+type CachedBox struct {
+	Box          Box
+	MaxEntrySize common.FileSize
+	MaxTotalSize common.FileSize
+
+	mutex     sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	totalSize common.FileSize
+}
+
+func NewCachedBox(box Box, maxEntrySize, maxTotalSize common.FileSize) *CachedBox {
+	return &CachedBox{
+		Box:          box,
+		MaxEntrySize: maxEntrySize,
+		MaxTotalSize: maxTotalSize,
+	}
+}
+
+type cacheEntry struct {
+	key      string
+	info     common.FileInfo
+	modTime  time.Time
+	checksum string
+	content  []byte
+}
+
+func (instance *cacheEntry) matches(fi common.FileInfo) bool {
+	return instance.info.Size() == fi.Size() &&
+		instance.modTime.Equal(fi.ModTime()) &&
+		instance.checksum == checksumOf(fi)
+}
+
+func checksumOf(fi common.FileInfo) string {
+	if efi, ok := fi.(common.ExtendedFileInfo); ok {
+		return efi.ChecksumString()
+	}
+	return ""
+}
+
+func (instance *CachedBox) Open(name string) (common.File, error) {
+	fi, err := instance.Box.Info(name)
+	if err != nil || fi.IsDir() || common.FileSize(fi.Size()) > instance.MaxEntrySize {
+		return instance.Box.Open(name)
+	}
+
+	key := entry.CleanPath(name)
+	if cached := instance.get(key, fi); cached != nil {
+		return &cachedFile{Reader: bytes.NewReader(cached.content), box: instance.Box, info: cached.info}, nil
+	}
+
+	f, err := instance.Box.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	info, err := f.GetFileInfo()
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, common.NewPathError("open", name, err)
+	}
+	instance.put(&cacheEntry{
+		key:      key,
+		info:     info,
+		modTime:  info.ModTime(),
+		checksum: checksumOf(info),
+		content:  content,
+	})
+	return &cachedFile{Reader: bytes.NewReader(content), box: instance.Box, info: info}, nil
+}
+
+func (instance *CachedBox) get(key string, fi common.FileInfo) *cacheEntry {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	if element, ok := instance.entries[key]; !ok {
+		return nil
+	} else if cached := element.Value.(*cacheEntry); !cached.matches(fi) {
+		instance.remove(element)
+		return nil
+	} else {
+		instance.lru.MoveToFront(element)
+		return cached
+	}
+}
+
+func (instance *CachedBox) put(cached *cacheEntry) {
+	size := common.FileSize(len(cached.content))
+	if size > instance.MaxEntrySize || size > instance.MaxTotalSize {
+		return
+	}
+
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	if instance.entries == nil {
+		instance.entries = make(map[string]*list.Element)
+		instance.lru = list.New()
+	}
+	if element, ok := instance.entries[cached.key]; ok {
+		instance.remove(element)
+	}
+	for instance.totalSize+size > instance.MaxTotalSize {
+		instance.remove(instance.lru.Back())
+	}
+	instance.entries[cached.key] = instance.lru.PushFront(cached)
+	instance.totalSize += size
+}
+
+func (instance *CachedBox) remove(element *list.Element) {
+	cached := instance.lru.Remove(element).(*cacheEntry)
+	delete(instance.entries, cached.key)
+	instance.totalSize -= common.FileSize(len(cached.content))
+}
+
+// Invalidate removes the given file from the cache.
+func (instance *CachedBox) Invalidate(name string) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	if element, ok := instance.entries[entry.CleanPath(name)]; ok {
+		instance.remove(element)
+	}
+}
+
+// TotalSize returns the size of all currently cached files.
+func (instance *CachedBox) TotalSize() common.FileSize {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	return instance.totalSize
+}
+
+func (instance *CachedBox) Info(name string) (common.FileInfo, error) {
+	return instance.Box.Info(name)
+}
+
+func (instance *CachedBox) ForEach(predicate common.FilePredicate, callback func(common.FileInfo) error) error {
+	if ib, ok := instance.Box.(Iterable); ok {
+		return ib.ForEach(predicate, callback)
+	}
+	return ErrBoxIterationNotSupported
+}
+
+func (instance *CachedBox) Watch(listener common.ChangeListener) (io.Closer, error) {
+	if wb, ok := instance.Box.(Watchable); ok {
+		return wb.Watch(func(event common.ChangeEvent) {
+			instance.Invalidate(event.Path)
+			listener(event)
+		})
+	}
+	return nil, ErrBoxWatchNotSupported
+}
+
+func (instance *CachedBox) Close() error {
+	instance.mutex.Lock()
+	instance.entries = nil
+	instance.lru = nil
+	instance.totalSize = 0
+	instance.mutex.Unlock()
+	return instance.Box.Close()
+}
+
+type cachedFile struct {
+	*bytes.Reader
+	box    Box
+	info   common.FileInfo
+	closed bool
+}
+
+func (instance *cachedFile) Close() error {
+	if instance.closed {
+		return common.NewPathError("close", instance.info.Path(), common.ErrAlreadyClosed)
+	}
+	instance.closed = true
+	return nil
+}
+
+func (instance *cachedFile) Readdir(count int) ([]os.FileInfo, error) {
+	f, err := instance.box.Open(instance.info.Path())
+	if err != nil {
+		return nil, err
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	return f.Readdir(count)
+}
+
+func (instance *cachedFile) Stat() (os.FileInfo, error) {
+	return instance.info, nil
+}
+
+func (instance *cachedFile) GetFileInfo() (common.FileInfo, error) {
+	return instance.info, nil
+}
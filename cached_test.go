@@ -0,0 +1,65 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_CachedBox(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt":   "aaaa",
+		"b.txt":   "bbbb",
+		"c.txt":   "cccc",
+		"big.txt": "0123456789",
+	})
+	defer removeAllForT(dir, t)
+	fsBox, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+	box := NewCachedBox(fsBox, 8, 10)
+
+	read := func(name string) string {
+		f, err := box.Open(name)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer func() {
+			assert.NoError(t, f.Close())
+		}()
+		_, err = f.Seek(1, io.SeekStart)
+		assert.NoError(t, err)
+		b, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "aaa", read("a.txt"))
+	assert.Equal(t, "bbb", read("/b.txt"))
+	assert.Equal(t, common.FileSize(8), box.TotalSize())
+
+	assert.Equal(t, "aaa", read("a.txt"))
+	assert.Equal(t, "ccc", read("c.txt"))
+	assert.Equal(t, common.FileSize(8), box.TotalSize())
+	assert.Contains(t, box.entries, "a.txt")
+	assert.NotContains(t, box.entries, "b.txt")
+
+	assert.Equal(t, "123456789", read("big.txt"))
+	assert.NotContains(t, box.entries, "big.txt")
+
+	filename := filepath.Join(dir, "a.txt")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("xxxx"), 0644))
+	assert.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Hour)))
+	assert.Equal(t, "xxx", read("a.txt"))
+
+	f, err := box.Open("a.txt")
+	assert.NoError(t, err)
+	_, err = f.Readdir(-1)
+	assert.Error(t, err)
+	assert.NoError(t, f.Close())
+}
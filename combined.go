@@ -4,41 +4,70 @@ import (
 	"bytes"
 	"errors"
 	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io"
 	"os"
+	"path"
+	"strings"
 )
 
+// WhiteoutPrefix marks files which are hiding the file (or directory) with the
+// same name without this prefix of all lower boxes of a CombinedBox.
+// Example: The file "foo/.wh.bar.txt" of the first box hides the file
+// "foo/bar.txt" of all following boxes. Whiteout files are only hidden in
+// boxes which have following boxes; the last box has nothing to hide, so its
+// files are served as they are.
+const WhiteoutPrefix = ".wh."
+
+// CombinedBox combines several boxes where the first box which contains a
+// requested file wins. Files of lower boxes can be hidden by upper boxes using
+// whiteout files (see WhiteoutPrefix).
 type CombinedBox []Box
 
 func (instance CombinedBox) Open(name string) (common.File, error) {
-	for _, box := range instance {
-		if f, err := box.Open(name); os.IsNotExist(err) {
-			continue
-		} else if err != nil {
-			return nil, err
-		} else {
+	for i, box := range instance {
+		if instance.isWhiteoutIn(i, name) {
+			// Markers of upper boxes are never visible.
+		} else if f, err := box.Open(name); err == nil {
 			return f, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if HasWhiteoutFor(box, name) {
+			break
 		}
 	}
 	return nil, common.NewPathError("open", name, os.ErrNotExist)
 }
 
 func (instance CombinedBox) Info(name string) (common.FileInfo, error) {
-	for _, box := range instance {
-		if fi, err := box.Info(name); os.IsNotExist(err) {
-			continue
-		} else if err != nil {
-			return nil, err
-		} else {
+	for i, box := range instance {
+		if instance.isWhiteoutIn(i, name) {
+			// Markers of upper boxes are never visible.
+		} else if fi, err := box.Info(name); err == nil {
 			return fi, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if HasWhiteoutFor(box, name) {
+			break
 		}
 	}
 	return nil, common.NewPathError("info", name, os.ErrNotExist)
 }
 
 func (instance CombinedBox) Close() error {
+	closers := make([]io.Closer, len(instance))
+	for i, box := range instance {
+		closers[i] = box
+	}
+	return closeAll(closers)
+}
+
+func closeAll(closers []io.Closer) error {
 	var errs []error
-	for _, box := range instance {
-		if err := box.Close(); err != nil {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -59,19 +88,101 @@ func (instance CombinedBox) Close() error {
 	}
 }
 
+// ForEach reports every visible file of this box exactly once. Files which are
+// shadowed by upper boxes or hidden by whiteouts are not reported. Boxes which
+// are not Iterable are skipped but still shadow the files of lower boxes.
 func (instance CombinedBox) ForEach(predicate common.FilePredicate, callback func(common.FileInfo) error) error {
-	for _, box := range instance {
-		if ib, ok := box.(Iterable); ok {
-			if err := ib.ForEach(predicate, callback); err != nil {
-				return err
+	return instance.ForEachWithBox(predicate, func(_ Box, fi common.FileInfo) error {
+		return callback(fi)
+	})
+}
+
+// ForEachWithBox works like ForEach but reports also the box which provides
+// the visible file.
+func (instance CombinedBox) ForEachWithBox(predicate common.FilePredicate, callback func(Box, common.FileInfo) error) error {
+	seen := make(map[string]bool)
+	iterated := false
+	for i, box := range instance {
+		ib, ok := box.(Iterable)
+		if !ok {
+			continue
+		}
+		iterated = true
+		upper := instance[:i]
+		if err := ib.ForEach(predicate, func(fi common.FileInfo) error {
+			p := entry.CleanPath(fi.Path())
+			if instance.isWhiteoutIn(i, p) || seen[p] {
+				return nil
+			}
+			seen[p] = true
+			for _, ub := range upper {
+				if _, iterable := ub.(Iterable); !iterable && !IsWhiteout(p) {
+					if ufi, err := ub.Info(p); err == nil {
+						return callback(ub, ufi)
+					} else if !os.IsNotExist(err) {
+						return err
+					}
+				}
+				if HasWhiteoutFor(ub, p) {
+					return nil
+				}
 			}
-		} else {
-			return ErrBoxIterationNotSupported
+			return callback(box, fi)
+		}); err != nil {
+			return err
 		}
 	}
+	if !iterated && len(instance) > 0 {
+		return ErrBoxIterationNotSupported
+	}
 	return nil
 }
 
+// IsWhiteout returns true if the given path is a whiteout file (see WhiteoutPrefix).
+func IsWhiteout(name string) bool {
+	return strings.HasPrefix(path.Base(name), WhiteoutPrefix)
+}
+
+// isWhiteoutIn returns true if the given path is a whiteout file which hides
+// files of boxes following the box at the given index.
+func (instance CombinedBox) isWhiteoutIn(i int, name string) bool {
+	return i < len(instance)-1 && IsWhiteout(name)
+}
+
+// HasWhiteoutFor returns true if the given box contains a whiteout file for the
+// given path or one of its parent directories (see WhiteoutPrefix).
+func HasWhiteoutFor(box Box, name string) bool {
+	for p := entry.CleanPath(name); p != "" && p != "." && p != "/"; p = path.Dir(p) {
+		dir, base := path.Split(p)
+		if _, err := box.Info(dir + WhiteoutPrefix + base); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch watches all boxes which are Watchable. If none of them is Watchable
+// ErrBoxWatchNotSupported will be returned.
+func (instance CombinedBox) Watch(listener common.ChangeListener) (io.Closer, error) {
+	var closers []io.Closer
+	for _, box := range instance {
+		if wb, ok := box.(Watchable); ok {
+			if closer, err := wb.Watch(listener); err != nil {
+				_ = closeAll(closers)
+				return nil, err
+			} else {
+				closers = append(closers, closer)
+			}
+		}
+	}
+	if len(closers) == 0 {
+		return nil, ErrBoxWatchNotSupported
+	}
+	return common.NewOnceCloser(func() error {
+		return closeAll(closers)
+	}), nil
+}
+
 func (instance CombinedBox) With(box Box) CombinedBox {
 	return append(instance, box)
 }
@@ -0,0 +1,163 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_CombinedBox_ForEachWithBox(t *testing.T) {
+	upperDir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt":       "upper a",
+		".wh.b.txt":   "",
+		".wh.dir":     "",
+		"upper.txt":   "upper",
+		"sub/c.txt":   "upper c",
+		"sub/.wh.xyz": "",
+	})
+	defer removeAllForT(upperDir, t)
+	lowerDir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt":     "lower a",
+		"b.txt":     "lower b",
+		"dir/d.txt": "lower d",
+		"sub/c.txt": "lower c",
+		"sub/e.txt": "lower e",
+		"lower.txt": "lower",
+	})
+	defer removeAllForT(lowerDir, t)
+
+	upper, err := fs.OpenBox(upperDir)
+	assert.NoError(t, err)
+	lower, err := fs.OpenBox(lowerDir)
+	assert.NoError(t, err)
+	box := CombinedBox{upper, lower}
+
+	actual := map[string]Box{}
+	assert.NoError(t, box.ForEachWithBox(nil, func(b Box, fi common.FileInfo) error {
+		_, alreadyReported := actual[fi.Path()]
+		assert.False(t, alreadyReported, "reported twice: %s", fi.Path())
+		actual[fi.Path()] = b
+		return nil
+	}))
+	assert.Equal(t, map[string]Box{
+		"a.txt":     upper,
+		"upper.txt": upper,
+		"sub/c.txt": upper,
+		"sub/e.txt": lower,
+		"lower.txt": lower,
+	}, actual)
+
+	_, err = box.Open("b.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = box.Info("dir/d.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = box.Info("sub/e.txt")
+	assert.NoError(t, err)
+
+	_, err = box.Open(".wh.b.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = box.Info("/sub/.wh.xyz")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_CombinedBox_whiteoutsOfLastBox(t *testing.T) {
+	upperDir := tempDirWithFilesForT(t, map[string]string{
+		".wh.a.txt": "",
+	})
+	defer removeAllForT(upperDir, t)
+	lowerDir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt":     "lower a",
+		".wh.b.txt": "lower marker",
+	})
+	defer removeAllForT(lowerDir, t)
+
+	upper, err := fs.OpenBox(upperDir)
+	assert.NoError(t, err)
+	lower, err := fs.OpenBox(lowerDir)
+	assert.NoError(t, err)
+
+	paths := func(box CombinedBox) (result []string) {
+		assert.NoError(t, box.ForEach(nil, func(fi common.FileInfo) error {
+			result = append(result, fi.Path())
+			return nil
+		}))
+		return
+	}
+
+	assert.Equal(t, []string{".wh.b.txt"}, paths(CombinedBox{upper, lower}))
+	_, err = CombinedBox{upper, lower}.Open("a.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = CombinedBox{upper, lower}.Info(".wh.a.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = CombinedBox{upper, lower}.Info(".wh.b.txt")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{".wh.a.txt"}, paths(CombinedBox{upper}))
+	f, err := CombinedBox{upper}.Open(".wh.a.txt")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+}
+
+func Test_CombinedBox_ForEach_withNotIterable(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "a",
+		"b.txt": "b",
+	})
+	defer removeAllForT(dir, t)
+	fsBox, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	notIterable := notIterableBox{fsBox}
+	box := CombinedBox{notIterable, fsBox}
+
+	actual := map[string]Box{}
+	assert.NoError(t, box.ForEachWithBox(nil, func(b Box, fi common.FileInfo) error {
+		actual[fi.Path()] = b
+		return nil
+	}))
+	assert.Equal(t, map[string]Box{
+		"a.txt": notIterable,
+		"b.txt": notIterable,
+	}, actual)
+
+	assert.Equal(t, ErrBoxIterationNotSupported, CombinedBox{notIterable}.ForEach(nil, func(common.FileInfo) error {
+		return nil
+	}))
+}
+
+type notIterableBox struct {
+	delegate Box
+}
+
+func (instance notIterableBox) Open(name string) (common.File, error) {
+	return instance.delegate.Open(name)
+}
+
+func (instance notIterableBox) Info(name string) (common.FileInfo, error) {
+	return instance.delegate.Info(name)
+}
+
+func (instance notIterableBox) Close() error {
+	return instance.delegate.Close()
+}
+
+func tempDirWithFilesForT(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "goxr-test-")
+	assert.NoError(t, err)
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+	return dir
+}
+
+func removeAllForT(p string, t *testing.T) {
+	if err := os.RemoveAll(p); err != nil {
+		t.Errorf("cannot remove %s: %v", p, err)
+	}
+}
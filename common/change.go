@@ -0,0 +1,57 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+type ChangeType uint8
+
+const (
+	ChangeTypeCreated  ChangeType = 1
+	ChangeTypeModified ChangeType = 2
+	ChangeTypeRemoved  ChangeType = 3
+)
+
+func (instance ChangeType) String() string {
+	switch instance {
+	case ChangeTypeCreated:
+		return "created"
+	case ChangeTypeModified:
+		return "modified"
+	case ChangeTypeRemoved:
+		return "removed"
+	default:
+		return fmt.Sprintf("unknown-change-type-%d", instance)
+	}
+}
+
+type ChangeEvent struct {
+	Type ChangeType
+	Path string
+}
+
+func (instance ChangeEvent) String() string {
+	return fmt.Sprintf("%v: %s", instance.Type, instance.Path)
+}
+
+type ChangeListener func(ChangeEvent)
+
+// NewOnceCloser creates an io.Closer which executes the given function only once.
+func NewOnceCloser(f func() error) io.Closer {
+	return &onceCloser{f: f}
+}
+
+type onceCloser struct {
+	f    func() error
+	once sync.Once
+	err  error
+}
+
+func (instance *onceCloser) Close() error {
+	instance.once.Do(func() {
+		instance.err = instance.f()
+	})
+	return instance.err
+}
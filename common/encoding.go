@@ -0,0 +1,175 @@
+package common
+
+import (
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Encoding is a content encoding (compression) as used by Accept-Encoding and
+// Content-Encoding of HTTP.
+type Encoding string
+
+const (
+	EncodingBrotli = Encoding("br")
+	EncodingZstd   = Encoding("zstd")
+	EncodingGzip   = Encoding("gzip")
+)
+
+// Encodings contains all supported encodings in order of preference.
+var Encodings = []Encoding{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+// CompressionLevel selects between speed and size of the encoded content.
+type CompressionLevel int
+
+const (
+	// CompressionLevelDefault is a good balance between speed and size
+	// (useful for compression on the fly).
+	CompressionLevelDefault = CompressionLevel(0)
+	// CompressionLevelBest produces the smallest content (useful at build time).
+	CompressionLevelBest = CompressionLevel(1)
+)
+
+// DefaultCompressibleMimeTypes are the mime types which are worth to compress.
+// Entries ending with / are matching all types with this prefix.
+var DefaultCompressibleMimeTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"application/manifest+json",
+	"application/wasm",
+	"application/xml",
+	"application/xhtml+xml",
+	"image/svg+xml",
+	"font/ttf",
+	"font/otf",
+}
+
+func ParseEncoding(plain string) (Encoding, error) {
+	candidate := Encoding(strings.ToLower(strings.TrimSpace(plain)))
+	for _, encoding := range Encodings {
+		if encoding == candidate {
+			return encoding, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported encoding: %s", plain)
+}
+
+func (instance *Encoding) Set(plain string) error {
+	if v, err := ParseEncoding(plain); err != nil {
+		return err
+	} else {
+		*instance = v
+		return nil
+	}
+}
+
+func (instance Encoding) String() string {
+	return string(instance)
+}
+
+// Extension returns the file extension of files with content of this encoding.
+func (instance Encoding) Extension() string {
+	switch instance {
+	case EncodingBrotli:
+		return ".br"
+	case EncodingZstd:
+		return ".zst"
+	case EncodingGzip:
+		return ".gz"
+	}
+	return ""
+}
+
+// NewWriter returns a writer which encodes everything written to it into to.
+// It has to be closed to flush the remaining content.
+func (instance Encoding) NewWriter(to io.Writer, level CompressionLevel) (io.WriteCloser, error) {
+	switch instance {
+	case EncodingBrotli:
+		if level == CompressionLevelBest {
+			return brotli.NewWriterLevel(to, brotli.BestCompression), nil
+		}
+		return brotli.NewWriterLevel(to, brotli.DefaultCompression), nil
+	case EncodingZstd:
+		if level == CompressionLevelBest {
+			return zstd.NewWriter(to, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		}
+		return zstd.NewWriter(to, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	case EncodingGzip:
+		if level == CompressionLevelBest {
+			return gzip.NewWriterLevel(to, gzip.BestCompression)
+		}
+		return gzip.NewWriterLevel(to, gzip.DefaultCompression)
+	}
+	return nil, fmt.Errorf("unsupported encoding: %s", instance)
+}
+
+// EncodingOfFilename returns the encoding of the given filename based on its extension.
+func EncodingOfFilename(filename string) (Encoding, bool) {
+	ext := path.Ext(filename)
+	for _, encoding := range Encodings {
+		if encoding.Extension() == ext {
+			return encoding, true
+		}
+	}
+	return "", false
+}
+
+// IsCompressibleMimeType returns true if the given mime type (parameters are
+// ignored) matches one of the given patterns (see DefaultCompressibleMimeTypes).
+func IsCompressibleMimeType(mimeType string, patterns []string) bool {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(mimeType, pattern) {
+			return true
+		} else if pattern == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectEncoding selects the best of the given candidates (in order of
+// preference) which is accepted by the given Accept-Encoding header.
+// Candidates with a higher quality value of the header are preferred.
+func SelectEncoding(acceptEncoding string, candidates []Encoding) (Encoding, bool) {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		qualities[name] = q
+	}
+
+	var result Encoding
+	best := 0.0
+	for _, candidate := range candidates {
+		q, ok := qualities[string(candidate)]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > best {
+			result, best = candidate, q
+		}
+	}
+	return result, best > 0
+}
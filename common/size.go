@@ -0,0 +1,30 @@
+package common
+
+import (
+	"github.com/c2h5oh/datasize"
+)
+
+func (instance *FileSize) Set(plain string) error {
+	var v datasize.ByteSize
+	if err := v.UnmarshalText([]byte(plain)); err != nil {
+		return err
+	}
+	*instance = FileSize(v)
+	return nil
+}
+
+func (instance FileSize) String() string {
+	return datasize.ByteSize(instance).HumanReadable()
+}
+
+func (instance FileSize) MarshalYAML() (interface{}, error) {
+	return datasize.ByteSize(instance).String(), nil
+}
+
+func (instance *FileSize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain string
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	return instance.Set(plain)
+}
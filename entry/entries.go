@@ -60,6 +60,19 @@ func (instance *Entries) Replace(pathname string, entry *Entry) error {
 	return nil
 }
 
+func (instance *Entries) Remove(pathname string) error {
+	if instance == nil || *instance == nil {
+		return os.ErrNotExist
+	}
+
+	cleanedPath := CleanPath(pathname)
+	if _, alreadyContained := (*instance)[cleanedPath]; !alreadyContained {
+		return os.ErrNotExist
+	}
+	delete(*instance, cleanedPath)
+	return nil
+}
+
 func (instance Entries) Filter(predicate Predicate) (Entries, error) {
 	if instance == nil {
 		return Entries{}, nil
@@ -55,13 +55,18 @@ func (instance Entry) String() string {
 }
 
 func (instance Entry) ChecksumString() string {
+	return instance.Checksum.String()
+}
+
+type Sha256Checksum [sha256.Size]byte
+
+func (instance Sha256Checksum) String() string {
 	buf := new(bytes.Buffer)
 	encoder := base64.NewEncoder(base64.URLEncoding, buf)
-	common.MustWrite(instance.Checksum[:], encoder)
+	common.MustWrite(instance[:], encoder)
 	return buf.String()
 }
 
-type Sha256Checksum [sha256.Size]byte
 type Meta map[string]interface{}
 
 type Predicate func(path string, entry *Entry) (bool, error)
@@ -21,6 +21,9 @@ func (instance *File) Close() error {
 	}
 
 	instance.closed = true
+	if closer, ok := instance.entryReader.(io.Closer); ok {
+		return closer.Close()
+	}
 	return nil
 }
 
@@ -1,27 +1,30 @@
 module github.com/echocat/goxr
 
 require (
-	github.com/c2h5oh/datasize v0.0.0-20200112174442-28bbd4740fee // performance tests
+	github.com/andybalholm/brotli v1.2.2
+	github.com/c2h5oh/datasize v0.0.0-20200112174442-28bbd4740fee
 	github.com/echocat/slf4g v1.8.4
 	github.com/echocat/slf4g/native v1.8.4
 	github.com/edsrzf/mmap-go v1.2.0
+	github.com/klauspost/compress v1.19.1
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.12.1
 	github.com/urfave/cli v1.22.17
 	github.com/valyala/fasthttp v1.73.0
 	github.com/vmihailenco/msgpack v4.0.4+incompatible
+	golang.org/x/net v0.57.0
+	golang.org/x/tools v0.47.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
-	github.com/andybalholm/brotli v1.2.2 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.7 // indirect
 	github.com/golang/protobuf v1.3.1 // indirect
-	github.com/klauspost/compress v1.19.1 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.5 // indirect
-	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/mod v0.38.0 // indirect
+	golang.org/x/sync v0.22.0 // indirect
 	golang.org/x/sys v0.47.0 // indirect
 	google.golang.org/appengine v1.6.5 // indirect
 )
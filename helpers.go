@@ -0,0 +1,189 @@
+package goxr
+
+import (
+	"fmt"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WalkFunc is called by Walk for every file and directory. If it returns
+// filepath.SkipDir for a directory its content is skipped. For a file the
+// remaining files of the same directory are skipped.
+type WalkFunc func(pathname string, info common.FileInfo, err error) error
+
+// ReadFile reads the whole content of the given file of the box.
+func ReadFile(box Box, name string) (content []byte, rErr error) {
+	f, err := box.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil && rErr == nil {
+			rErr = err
+		}
+	}()
+	return ioutil.ReadAll(f)
+}
+
+// ReadString reads the whole content of the given file of the box as string.
+func ReadString(box Box, name string) (string, error) {
+	b, err := ReadFile(box, name)
+	return string(b), err
+}
+
+// MustReadFile works like ReadFile but panics on errors. This is useful for
+// initialization code.
+func MustReadFile(box Box, name string) []byte {
+	if b, err := ReadFile(box, name); err != nil {
+		panic(fmt.Sprintf("cannot read %s: %v", name, err))
+	} else {
+		return b
+	}
+}
+
+// Glob returns the sorted paths of all files of the box which are matching
+// the given pattern. It follows the semantics of path.Match for every segment
+// of the path and supports additionally "**" as segment which matches zero or
+// more segments. If the box is not Iterable only patterns without any
+// meta characters can be resolved.
+func Glob(box Box, pattern string) ([]string, error) {
+	pattern = entry.CleanPath(pattern)
+	patternSegments := strings.Split(pattern, "/")
+	for _, segment := range patternSegments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	ib, ok := box.(Iterable)
+	if !ok {
+		if hasMeta(pattern) {
+			return nil, ErrBoxIterationNotSupported
+		} else if _, err := box.Info(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	var result []string
+	if err := ib.ForEach(func(candidate string) (bool, error) {
+		return matchSegments(patternSegments, strings.Split(entry.CleanPath(candidate), "/"))
+	}, func(fi common.FileInfo) error {
+		result = append(result, entry.CleanPath(fi.Path()))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func matchSegments(pattern []string, candidate []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(candidate); i++ {
+				if ok, err := matchSegments(pattern[1:], candidate[i:]); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(candidate) == 0 {
+			return false, nil
+		}
+		if ok, err := path.Match(pattern[0], candidate[0]); err != nil || !ok {
+			return false, err
+		}
+		pattern, candidate = pattern[1:], candidate[1:]
+	}
+	return len(candidate) == 0, nil
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Walk calls fn for every file of the box below root (or root itself if it
+// is a file) in lexical order. Every directory below root is walked before
+// its content. If the box is not Iterable only a file root
+// can be walked.
+func Walk(box Box, root string, fn WalkFunc) error {
+	root = entry.CleanPath(root)
+	if root == "." || root == "/" {
+		root = ""
+	}
+
+	ib, ok := box.(Iterable)
+	if !ok {
+		if fi, err := box.Info(root); err == nil && !fi.IsDir() {
+			return ignoreSkipDir(fn(root, fi, nil))
+		}
+		return fn(root, nil, ErrBoxIterationNotSupported)
+	}
+
+	var infos []common.FileInfo
+	if err := ib.ForEach(func(candidate string) (bool, error) {
+		candidate = entry.CleanPath(candidate)
+		return root == "" || candidate == root || strings.HasPrefix(candidate, root+"/"), nil
+	}, func(fi common.FileInfo) error {
+		infos = append(infos, fi)
+		return nil
+	}); err != nil {
+		return fn(root, nil, err)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return entry.CleanPath(infos[i].Path()) < entry.CleanPath(infos[j].Path())
+	})
+
+	skippedDir := ""
+	walkedDirs := map[string]bool{}
+	for _, fi := range infos {
+		p := entry.CleanPath(fi.Path())
+		if skippedDir != "" && strings.HasPrefix(p, skippedDir+"/") {
+			continue
+		}
+		if dir, err := walkDirectoriesOf(root, p, walkedDirs, fn); err == filepath.SkipDir {
+			skippedDir = dir
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := fn(p, fi, nil); err == filepath.SkipDir {
+			if skippedDir = path.Dir(p); skippedDir == "." {
+				return nil
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkDirectoriesOf calls fn for every not already walked parent directory of
+// the given file below root - starting with the top most one. It returns the
+// directory of which fn returned an error.
+func walkDirectoriesOf(root, file string, walked map[string]bool, fn WalkFunc) (string, error) {
+	var dirs []string
+	for dir := path.Dir(file); dir != "." && dir != "/" && dir != root && !walked[dir]; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		walked[dirs[i]] = true
+		if err := fn(dirs[i], mountDirInfo(dirs[i]), nil); err != nil {
+			return dirs[i], err
+		}
+	}
+	return "", nil
+}
+
+func ignoreSkipDir(err error) error {
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ReadFile(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"a.txt": "a",
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	actual, err := ReadString(box, "a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", actual)
+	assert.Equal(t, []byte("a"), MustReadFile(box, "/a.txt"))
+
+	_, err = ReadFile(box, "b.txt")
+	assert.True(t, os.IsNotExist(err))
+	assert.Panics(t, func() {
+		MustReadFile(box, "b.txt")
+	})
+}
+
+func Test_Glob(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"index.html":            "",
+		"css/main.css":          "",
+		"css/vendor/reset.css":  "",
+		"js/app.js":             "",
+		"templates/a.tmpl":      "",
+		"templates/mail/b.tmpl": "",
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	assertGlob := func(pattern string, expected ...string) {
+		actual, err := Glob(box, pattern)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, "pattern: %s", pattern)
+	}
+
+	assertGlob("*.html", "index.html")
+	assertGlob("css/*.css", "css/main.css")
+	assertGlob("**/*.css", "css/main.css", "css/vendor/reset.css")
+	assertGlob("/templates/**", "templates/a.tmpl", "templates/mail/b.tmpl")
+	assertGlob("templates/**/*.tmpl", "templates/a.tmpl", "templates/mail/b.tmpl")
+	assertGlob("**/app.js", "js/app.js")
+	assertGlob("*.txt")
+
+	_, err = Glob(box, "[")
+	assert.Error(t, err)
+
+	actual, err := Glob(notIterableBox{box}, "js/app.js")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"js/app.js"}, actual)
+	_, err = Glob(notIterableBox{box}, "js/*.js")
+	assert.Equal(t, ErrBoxIterationNotSupported, err)
+}
+
+func Test_Walk(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"a/1.txt":   "",
+		"a/2.txt":   "",
+		"a/b/3.txt": "",
+		"a/c/4.txt": "",
+		"a/c/5.txt": "",
+		"d/6.txt":   "",
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	var actual []string
+	assert.NoError(t, Walk(box, "a", func(pathname string, info common.FileInfo, err error) error {
+		assert.NoError(t, err)
+		actual = append(actual, pathname)
+		if pathname == "a/c/4.txt" {
+			return filepath.SkipDir
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"a/1.txt", "a/2.txt", "a/b", "a/b/3.txt", "a/c", "a/c/4.txt"}, actual)
+}
+
+func Test_Walk_nested(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"a/1.txt":       "",
+		"a/b/2.txt":     "",
+		"a/b/c/3.txt":   "",
+		"a/b/c/d/4.txt": "",
+		"a/e/5.txt":     "",
+		"f/6.txt":       "",
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	var actual []string
+	var dirs []string
+	assert.NoError(t, Walk(box, "", func(pathname string, info common.FileInfo, err error) error {
+		assert.NoError(t, err)
+		actual = append(actual, pathname)
+		if info.IsDir() {
+			dirs = append(dirs, pathname)
+		}
+		if pathname == "a/b/2.txt" {
+			return filepath.SkipDir
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "a/1.txt", "a/b", "a/b/2.txt", "a/e", "a/e/5.txt", "f", "f/6.txt"}, actual)
+	assert.Equal(t, []string{"a", "a/b", "a/e", "f"}, dirs)
+
+	actual = nil
+	assert.NoError(t, Walk(box, "a", func(pathname string, info common.FileInfo, err error) error {
+		assert.NoError(t, err)
+		actual = append(actual, pathname)
+		if pathname == "a/b" {
+			return filepath.SkipDir
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"a/1.txt", "a/b", "a/e", "a/e/5.txt"}, actual)
+}
@@ -4,22 +4,29 @@ import (
 	"errors"
 	"github.com/echocat/goxr/box/packed"
 	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/manifest"
 	"github.com/echocat/goxr/runtime"
 	"github.com/echocat/goxr/usagescanner"
+	"github.com/echocat/slf4g"
 	"github.com/urfave/cli"
 	"os"
+	"strings"
 	"time"
 )
 
 type BaseCreateCommand struct {
 	BoxCommand
 
-	Name        string
-	Version     string
-	Description string
-	Build       common.CliTime
-	Revision    string
-	SourceFiles []string
+	ManifestFilename string
+	Manifest         manifest.Manifest
+	Build            common.CliTime
+	Revision         string
+	Fingerprint      cli.StringSlice
+	Forbidden        cli.StringSlice
+	Transform        cli.StringSlice
+	Policy           packed.Policy
+	Symlinks         packed.SymlinkPolicy
+	Precompress      cli.StringSlice
 }
 
 func NewBaseCreateCommand() BaseCreateCommand {
@@ -31,6 +38,12 @@ func NewBaseCreateCommand() BaseCreateCommand {
 
 func (instance *BaseCreateCommand) CliFlags() []cli.Flag {
 	return append(instance.BoxCommand.CliFlags(),
+		cli.StringFlag{
+			Name: "file, f",
+			Usage: "Reads name, version, description, bases and the <box filename> from the given manifest (" + manifest.DefaultFilename + ")." +
+				"\n     In this case only the optional <box filename> is accepted as argument which overrides the output of the manifest.",
+			Destination: &instance.ManifestFilename,
+		},
 		cli.GenericFlag{
 			Name:  "build, b",
 			Usage: "Defines the build timestamp of the created box. If not set the current time will be used.",
@@ -41,10 +54,84 @@ func (instance *BaseCreateCommand) CliFlags() []cli.Flag {
 			Usage:       "Defines the revision of the created box. If not set it will be one created based on the build timestamp.",
 			Destination: &instance.Revision,
 		},
+		cli.StringSliceFlag{
+			Name: "fingerprint",
+			Usage: "Regular expression of box paths which should be stored fingerprinted (app.js -> app.3f2a9c.js)." +
+				"\n     A " + packed.AssetManifestFilename + " which maps the logical to the fingerprinted paths will be added to the box.",
+			Value: &instance.Fingerprint,
+		},
+		cli.GenericFlag{
+			Name:  "maxTotalSize",
+			Usage: "Fails if the total size of all entries of the box exceeds the given size (for example 100MB).",
+			Value: &instance.Policy.MaxTotalSize,
+		},
+		cli.GenericFlag{
+			Name:  "maxEntrySize",
+			Usage: "Fails if the size of one entry of the box exceeds the given size (for example 10MB).",
+			Value: &instance.Policy.MaxEntrySize,
+		},
+		cli.StringSliceFlag{
+			Name: "forbidden",
+			Usage: "Fails if a file matches the given pattern (for example .env or *.pem)." +
+				"\n     Patterns without / are matched against the file name, all others against the whole path inside the box.",
+			Value: &instance.Forbidden,
+		},
+		cli.StringSliceFlag{
+			Name: "transform",
+			Usage: "Transforms the content of every entry which path matches <regexp> in format <regexp>=<transformer>[,<transformer>...]." +
+				"\n     Supported transformers: " + strings.Join(packed.ContentTransformerNames(), ", "),
+			Value: &instance.Transform,
+		},
+		cli.GenericFlag{
+			Name: "symlinks",
+			Usage: "Defines how symlinks inside of the bases are handled. Supported: follow, skip, link (default: follow)." +
+				"\n     follow: stores the content of the symlink target (loops will be detected), skip: ignores symlinks," +
+				"\n     link: stores the symlink itself which will be resolved inside of the box (the target has to be part of the base).",
+			Value: &instance.Symlinks,
+		},
+		cli.StringSliceFlag{
+			Name: "precompress",
+			Usage: "Stores for every compressible entry additionally siblings encoded with the given encodings (br, zstd or gzip)." +
+				"\n     Example: --precompress br --precompress gzip will store index.html, index.html.br and index.html.gz." +
+				"\n     goxr-server serves these siblings to clients which are accepting the encoding.",
+			Value: &instance.Precompress,
+		},
 	)
 }
 
 func (instance *BaseCreateCommand) BeforeCli(cli *cli.Context) error {
+	if err := instance.beforeCli(cli); err != nil {
+		return err
+	}
+	instance.Manifest.Fingerprint = append(instance.Manifest.Fingerprint, instance.Fingerprint...)
+	instance.Policy.Forbidden = instance.Forbidden
+	for _, plain := range instance.Transform {
+		if rule, err := manifest.ParseTransformRule(plain); err != nil {
+			return err
+		} else {
+			instance.Manifest.Transform = append(instance.Manifest.Transform, rule)
+		}
+	}
+	instance.Manifest.Policy = instance.Manifest.Policy.Merge(instance.Policy)
+	if !instance.Symlinks.IsZero() {
+		instance.Manifest.Symlinks = instance.Symlinks
+	}
+	for _, plain := range instance.Precompress {
+		for _, part := range strings.Split(plain, ",") {
+			if encoding, err := common.ParseEncoding(part); err != nil {
+				return err
+			} else {
+				instance.Manifest.Precompress = append(instance.Manifest.Precompress, encoding)
+			}
+		}
+	}
+	return instance.Manifest.Validate()
+}
+
+func (instance *BaseCreateCommand) beforeCli(cli *cli.Context) error {
+	if instance.ManifestFilename != "" {
+		return instance.beforeCliWithManifest(cli)
+	}
 	if err := instance.BoxCommand.BeforeCli(cli); err != nil {
 		return err
 	}
@@ -57,50 +144,122 @@ func (instance *BaseCreateCommand) BeforeCli(cli *cli.Context) error {
 	if cli.NArg() < 4 {
 		return errors.New("too few arguments provided - <description> missing")
 	}
-	instance.Name = cli.Args()[1]
-	instance.Version = cli.Args()[2]
-	instance.Description = cli.Args()[3]
-	instance.SourceFiles = cli.Args()[4:]
+	instance.Manifest.Name = cli.Args()[1]
+	instance.Manifest.Version = cli.Args()[2]
+	instance.Manifest.Description = cli.Args()[3]
+	for _, plain := range cli.Args()[4:] {
+		instance.Manifest.Bases = append(instance.Manifest.Bases, manifest.ParseBase(plain))
+	}
+	return nil
+}
+
+func (instance *BaseCreateCommand) beforeCliWithManifest(cli *cli.Context) error {
+	if m, err := manifest.OfFile(instance.ManifestFilename); err != nil {
+		return err
+	} else {
+		instance.Manifest = m
+	}
+	if cli.NArg() > 1 {
+		return errors.New("too many arguments provided - only an optional <box filename> is allowed if a manifest is used")
+	} else if cli.NArg() == 1 {
+		instance.Filename = cli.Args()[0]
+	} else if instance.Manifest.Output != "" {
+		instance.Filename = instance.Manifest.Output
+	} else {
+		return errors.New("neither a <box filename> provided nor an output defined in the manifest")
+	}
 	return nil
 }
 
-type DoWithWriterAndBasesFunc func(writer *packed.Writer, bases []string) error
+type DoWithWriterAndManifestFunc func(writer *packed.Writer, m manifest.Manifest) error
 
-func (instance *BaseCreateCommand) DoWithWriter(f DoWithWriterAndBasesFunc, om packed.OpenMode, wm packed.WriteMode) error {
-	return instance.BoxCommand.DoWithWriter(func(writer *packed.Writer) error {
-		bases, err := instance.resolveSourceFiles()
+func (instance *BaseCreateCommand) DoWithWriter(f DoWithWriterAndManifestFunc, om packed.OpenMode, wm packed.WriteMode) error {
+	return instance.DoWithWriterBasedOn(instance.Filename, f, om, wm)
+}
+
+// DoWithWriterBasedOn works like DoWithWriter but takes the content in front of
+// the box from the given base file (see packed.NewWriterBasedOn).
+func (instance *BaseCreateCommand) DoWithWriterBasedOn(base string, f DoWithWriterAndManifestFunc, om packed.OpenMode, wm packed.WriteMode) error {
+	return instance.BoxCommand.DoWithWriterBasedOn(base, func(writer *packed.Writer) error {
+		m, err := instance.resolveManifest()
 		if err != nil {
 			return err
 		}
 
 		box := writer.Box()
-		box.Name = instance.Name
-		box.Version = instance.Version
-		box.Description = instance.Description
+		box.Built = time.Now().Truncate(time.Millisecond)
+		m.ApplyTo(box)
 		if instance.Build.Time != nil {
 			box.Built = *instance.Build.Time
-		} else {
-			box.Built = time.Now().Truncate(time.Millisecond)
 		}
 		if instance.Revision != "" {
 			box.Revision = instance.Revision
-		} else {
+		} else if box.Revision == "" {
 			box.Revision = runtime.RandomRevision(box.Built)
 		}
 		box.BuiltBy = runtime.GetRuntime().ShortString()
 
-		return f(writer, bases)
+		return f(writer, m)
 	}, om, wm)
 }
 
-func (instance *BaseCreateCommand) resolveSourceFiles() ([]string, error) {
-	if len(instance.SourceFiles) > 0 {
-		return instance.SourceFiles, nil
+func (instance *BaseCreateCommand) WriteEntries(writer *packed.Writer, m manifest.Manifest, l log.Logger) error {
+	checker, err := m.Policy.NewChecker()
+	if err != nil {
+		return err
+	}
+	writer.PolicyChecker = checker
+	if err := m.WriteServerConfiguration(writer); err != nil {
+		return err
+	}
+	if !m.Symlinks.IsZero() {
+		writer.SymlinkPolicy = m.Symlinks
+	}
+	for i, base := range m.Bases {
+		sl := l.With("base", base)
+		sl.Infof("Adding files of %v...", base)
+		if err := m.WriteBase(writer, i, func(candidate *packed.WriteCandidate) error {
+			if err := checker.Intercept(candidate); err != nil {
+				return err
+			} else if candidate.Accept {
+				sl.
+					With("target", candidate.Target.Filename).
+					With("source", candidate.SourceFilename).
+					Infof("  %s", candidate.Target.Filename)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if len(m.Precompress) > 0 {
+		l.Infof("Adding precompressed entries (%v)...", m.Precompress)
+		if err := writer.WritePrecompressed(m.Precompress, nil); err != nil {
+			return err
+		}
+	}
+	return checker.Check()
+}
+
+func (instance *BaseCreateCommand) resolveManifest() (manifest.Manifest, error) {
+	result := instance.Manifest
+	if len(result.Bases) > 0 {
+		return result, nil
 	} else if cwd, err := os.Getwd(); err != nil {
-		return []string{}, err
-	} else if usages, err := usagescanner.ScanForUsages(cwd); err != nil {
-		return []string{}, err
+		return manifest.Manifest{}, err
+	} else if calls, err := usagescanner.Scan(cwd, usagescanner.Options{}); err != nil {
+		return manifest.Manifest{}, err
 	} else {
-		return usages.Resolve(), nil
+		for _, call := range calls {
+			for _, unresolved := range call.Unresolved {
+				log.With("position", unresolved.Position.String()).
+					With("expression", unresolved.Expression).
+					Warnf("Cannot resolve base of goxr.%s(..): %s; it will not be added to the box.", call.Function, unresolved.Reason)
+			}
+		}
+		for _, usage := range calls.Usages().Resolve() {
+			result.Bases = append(result.Bases, manifest.ParseBase(usage))
+		}
+		return result, nil
 	}
 }
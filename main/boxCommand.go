@@ -3,6 +3,7 @@ package main
 import (
 	"errors"
 	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/slf4g"
 	"github.com/urfave/cli"
 )
 
@@ -48,15 +49,26 @@ func (instance *BoxCommand) DoWithBox(f DoWithBoxFunc) (rErr error) {
 type DoWithWriterFunc func(*packed.Writer) error
 
 func (instance *BoxCommand) DoWithWriter(w DoWithWriterFunc, om packed.OpenMode, wm packed.WriteMode) (rErr error) {
+	return instance.DoWithWriterBasedOn(instance.Filename, w, om, wm)
+}
+
+// DoWithWriterBasedOn works like DoWithWriter but takes the content in front of
+// the box from the given base file (see packed.NewWriterBasedOn).
+func (instance *BoxCommand) DoWithWriterBasedOn(base string, w DoWithWriterFunc, om packed.OpenMode, wm packed.WriteMode) (rErr error) {
 	filename := instance.Filename
 	if filename == "" {
 		return errors.New("no filename provided")
 	}
-	if writer, err := packed.NewWriter(filename, om, wm); err != nil {
+	if writer, err := packed.NewWriterBasedOn(base, filename, om, wm); err != nil {
 		return err
 	} else {
 		defer func() {
-			if err := writer.Close(); err != nil {
+			if rErr != nil {
+				// Do not replace the file with the box of a failed build.
+				if err := writer.Abort(); err != nil {
+					log.WithError(err).Warn("Cannot remove incomplete box.")
+				}
+			} else if err := writer.Close(); err != nil {
 				rErr = err
 			}
 		}()
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/manifest"
 	"github.com/echocat/slf4g"
 	"github.com/urfave/cli"
 )
@@ -28,7 +29,7 @@ func (instance *CreateCommand) NewCliCommands() []cli.Command {
 	return []cli.Command{{
 		Name:      "create",
 		Usage:     "Creates a new box.",
-		ArgsUsage: "<box filename> <name> <version> <description> [[<prefix=>]<path to add>] ...",
+		ArgsUsage: "<box filename> <name> <version> <description> [[<prefix=>]<path to add>] ... | --file <manifest> [<box filename>]",
 		Before:    instance.BeforeCli,
 		Flags:     instance.CliFlags(),
 		Action:    instance.ExecuteFromCli,
@@ -41,7 +42,11 @@ func (instance *CreateCommand) NewCliCommands() []cli.Command {
    OR there is no [paths to add] specified:
      in this case this command searches in the current working directory for every *.go file
      that contains a goxr.OpenBox(..) or goxr.OpenBoxBy(..) statement and will use its specified
-     bases as paths to add to the target box.`,
+     bases as paths to add to the target box.
+
+   Alternatively a manifest (see --file) could be provided which describes the box metadata,
+   its bases (including explicit prefixes), filters, per entry metadata and the output file.
+   If it contains a "server" section this will be stored as server configuration inside the box.`,
 	}}
 }
 
@@ -67,7 +72,7 @@ func (instance *CreateCommand) CliFlags() []cli.Flag {
 }
 
 func (instance *CreateCommand) ExecuteFromCli(ctx *cli.Context) error {
-	return instance.DoWithWriter(func(writer *packed.Writer, bases []string) error {
+	return instance.DoWithWriter(func(writer *packed.Writer, m manifest.Manifest) error {
 		box := writer.Box()
 		l := log.
 			With("box", instance.Filename)
@@ -79,19 +84,6 @@ func (instance *CreateCommand) ExecuteFromCli(ctx *cli.Context) error {
 			With("built", box.Built).
 			Infof("Creating box %s...", instance.Filename)
 
-		for _, base := range bases {
-			sl := l.With("base", base)
-			sl.Infof("Adding files of %s...", base)
-			if err := writer.WriteFilesRecursive(base, func(candidate *packed.WriteCandidate) error {
-				sl.
-					With("target", candidate.Target.Filename).
-					With("source", candidate.SourceFilename).
-					Infof("  %s", candidate.Target.Filename)
-				return nil
-			}); err != nil {
-				return err
-			}
-		}
-		return nil
+		return instance.WriteEntries(writer, m, l)
 	}, instance.OpenMode, instance.WriteMode)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/manifest"
 	"github.com/echocat/goxr/runtime"
 	"github.com/echocat/slf4g"
 	"github.com/urfave/cli"
@@ -40,7 +41,7 @@ func (instance *CreateServerCommand) NewCliCommands() []cli.Command {
 	return []cli.Command{{
 		Name:      "createServer",
 		Usage:     "Creates a standalone server executable which contains a box.",
-		ArgsUsage: "<box filename> <name> <version> <description> [[<prefix=>]<path to add>] ...",
+		ArgsUsage: "<box filename> <name> <version> <description> [[<prefix=>]<path to add>] ... | --file <manifest> [<box filename>]",
 		Before:    instance.BeforeCli,
 		Flags:     instance.CliFlags(),
 		Action:    instance.ExecuteFromCli,
@@ -53,7 +54,11 @@ func (instance *CreateServerCommand) NewCliCommands() []cli.Command {
    OR there is no [paths to add] specified:
      in this case this command searches in the current working directory for every *.go file
      that contains a goxr.OpenBox(..) or goxr.OpenBoxBy(..) statement and will use its specified
-     bases as paths to add to the target box.`,
+     bases as paths to add to the target box.
+
+   Alternatively a manifest (see --file) could be provided which describes the box metadata,
+   its bases (including explicit prefixes), filters, per entry metadata and the output file.
+   If it contains a "server" section this will be stored as server configuration inside the box.`,
 	}}
 }
 
@@ -93,10 +98,15 @@ func (instance *CreateServerCommand) CliFlags() []cli.Flag {
 }
 
 func (instance *CreateServerCommand) ExecuteFromCli(*cli.Context) error {
-	if err := instance.createServerStub(instance.Filename); err != nil {
+	sourceFile, err := instance.downloadServerTemplate()
+	if err != nil {
 		return err
 	}
-	return instance.DoWithWriter(func(writer *packed.Writer, bases []string) error {
+	om := packed.OpenModeCreateOnly
+	if instance.Overwrite {
+		om = packed.OpenModeOpenOrCreate
+	}
+	return instance.DoWithWriterBasedOn(sourceFile, func(writer *packed.Writer, m manifest.Manifest) error {
 		box := writer.Box()
 		l := log.
 			With("box", instance.Filename)
@@ -108,47 +118,8 @@ func (instance *CreateServerCommand) ExecuteFromCli(*cli.Context) error {
 			With("built", box.Built).
 			Infof("Creating server %s...", instance.Filename)
 
-		for _, base := range bases {
-			sl := l.With("base", base)
-			sl.Infof("Adding files of %s...", base)
-			if err := writer.WriteFilesRecursive(base, func(candidate *packed.WriteCandidate) error {
-				sl.
-					With("target", candidate.Target.Filename).
-					With("source", candidate.SourceFilename).
-					Infof("  %s", candidate.Target.Filename)
-				return nil
-			}); err != nil {
-				return err
-			}
-		}
-		return nil
-	}, packed.OpenModeOpenOnly, packed.WriteModeNewOnly)
-}
-
-func (instance *CreateServerCommand) createServerStub(target string) error {
-	if sourceFile, err := instance.downloadServerTemplate(); err != nil {
-		return err
-	} else if fi, err := os.Open(sourceFile); err != nil {
-		return err
-	} else {
-		//noinspection GoUnhandledErrorResult
-		defer fi.Close()
-		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
-		if !instance.Overwrite {
-			flag |= os.O_EXCL
-		}
-		if fo, err := os.OpenFile(target, flag, 0755); err != nil {
-			return err
-		} else {
-			//noinspection GoUnhandledErrorResult
-			defer fo.Close()
-			if _, err := io.Copy(fo, fi); err != nil {
-				return err
-			} else {
-				return nil
-			}
-		}
-	}
+		return instance.WriteEntries(writer, m, l)
+	}, om, packed.WriteModeNewOnly)
 }
 
 func (instance *CreateServerCommand) downloadServerTemplate() (string, error) {
@@ -16,6 +16,7 @@ func main() {
 	app.Commands = append(app.Commands, CreateCommandInstance.NewCliCommands()...)
 	app.Commands = append(app.Commands, CreateServerCommandInstance.NewCliCommands()...)
 	app.Commands = append(app.Commands, ListCommandInstance.NewCliCommands()...)
+	app.Commands = append(app.Commands, ScanCommandInstance.NewCliCommands()...)
 	app.Commands = append(app.Commands, TruncateCommandInstance.NewCliCommands()...)
 
 	lv := value.NewProvider(native.DefaultProvider)
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr/manifest"
+	"github.com/echocat/goxr/usagescanner"
+	"github.com/urfave/cli"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ScanCommandInstance = NewScanCommand()
+
+type ScanCommand struct {
+	Directory string
+	Format    ScanFormat
+	Tags      cli.StringSlice
+	GOOS      string
+	GOARCH    string
+	Tests     bool
+
+	Output io.Writer
+}
+
+func NewScanCommand() *ScanCommand {
+	return &ScanCommand{
+		Format: ScanFormatText,
+		Output: os.Stdout,
+	}
+}
+
+func (instance *ScanCommand) NewCliCommands() []cli.Command {
+	return []cli.Command{{
+		Name:      "scan",
+		Usage:     "Shows all goxr.OpenBox(..) and goxr.OpenBoxBy(..) calls of the Go code.",
+		ArgsUsage: "[directory]",
+		Before:    instance.BeforeCli,
+		Flags:     instance.CliFlags(),
+		Action:    instance.ExecuteFromCli,
+		Description: `Searches in the given [directory] (default: current working directory) for every
+   goxr.OpenBox(..) or goxr.OpenBoxBy(..) call - the same way "create" does if no paths to add are
+   specified. Every call is reported with its position, the resolved bases and whether these exist.
+
+   Arguments which are not constant strings cannot be resolved and are reported as warnings.`,
+	}}
+}
+
+func (instance *ScanCommand) CliFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.GenericFlag{
+			Name:  "format",
+			Usage: "Format of the output (text or json).",
+			Value: &instance.Format,
+		},
+		cli.StringSliceFlag{
+			Name:  "tags",
+			Usage: "Build tags which are considered as satisfied.",
+			Value: &instance.Tags,
+		},
+		cli.StringFlag{
+			Name:        "goos",
+			Usage:       "Target operating system to consider instead of the current one.",
+			Destination: &instance.GOOS,
+		},
+		cli.StringFlag{
+			Name:        "goarch",
+			Usage:       "Target architecture to consider instead of the current one.",
+			Destination: &instance.GOARCH,
+		},
+		cli.BoolFlag{
+			Name:        "tests",
+			Usage:       "Scans also the *_test.go files.",
+			Destination: &instance.Tests,
+		},
+	}
+}
+
+func (instance *ScanCommand) BeforeCli(cli *cli.Context) error {
+	if cli.NArg() > 1 {
+		return errors.New("too many arguments provided - only an optional [directory] is allowed")
+	} else if cli.NArg() == 1 {
+		instance.Directory = cli.Args()[0]
+	} else {
+		instance.Directory = "."
+	}
+	return nil
+}
+
+func (instance *ScanCommand) ExecuteFromCli(*cli.Context) error {
+	calls, err := usagescanner.Scan(instance.Directory, usagescanner.Options{
+		Tags:   instance.Tags,
+		GOOS:   instance.GOOS,
+		GOARCH: instance.GOARCH,
+		Tests:  instance.Tests,
+	})
+	if err != nil {
+		return err
+	}
+	report := NewScanReport(calls)
+	if instance.Format == ScanFormatJson {
+		encoder := json.NewEncoder(instance.Output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return report.WriteText(instance.Output)
+}
+
+type ScanReport struct {
+	Calls []ScannedCall `json:"calls"`
+}
+
+type ScannedCall struct {
+	Position   string              `json:"position"`
+	Function   string              `json:"function"`
+	Bases      []ScannedBase       `json:"bases,omitempty"`
+	Unresolved []ScannedUnresolved `json:"unresolved,omitempty"`
+}
+
+// ScannedBase is a base of a ScannedCall. Path is the first existing of the
+// Candidates or the first of them if none exists.
+type ScannedBase struct {
+	Base       string   `json:"base"`
+	Path       string   `json:"path"`
+	Exists     bool     `json:"exists"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+type ScannedUnresolved struct {
+	Position   string `json:"position"`
+	Expression string `json:"expression"`
+	Reason     string `json:"reason"`
+}
+
+func NewScanReport(calls usagescanner.Calls) ScanReport {
+	cwd, _ := os.Getwd()
+	result := ScanReport{Calls: []ScannedCall{}}
+	for _, call := range calls {
+		sc := ScannedCall{
+			Position: relativePosition(cwd, call.Position.String()),
+			Function: call.Function,
+		}
+		for _, base := range call.Bases {
+			path, candidates, exists := call.ResolveBase(manifest.ParseBase(base).Path)
+			if !exists && len(candidates) > 0 {
+				path = candidates[0]
+			}
+			sc.Bases = append(sc.Bases, ScannedBase{
+				Base:       base,
+				Path:       path,
+				Exists:     exists,
+				Candidates: candidates,
+			})
+		}
+		for _, unresolved := range call.Unresolved {
+			sc.Unresolved = append(sc.Unresolved, ScannedUnresolved{
+				Position:   relativePosition(cwd, unresolved.Position.String()),
+				Expression: unresolved.Expression,
+				Reason:     unresolved.Reason,
+			})
+		}
+		result.Calls = append(result.Calls, sc)
+	}
+	return result
+}
+
+func (instance ScanReport) WriteText(to io.Writer) error {
+	for _, call := range instance.Calls {
+		if _, err := fmt.Fprintf(to, "%s: goxr.%s\n", call.Position, call.Function); err != nil {
+			return err
+		}
+		for _, base := range call.Bases {
+			state := "exists"
+			if !base.Exists {
+				state = "missing"
+			}
+			if _, err := fmt.Fprintf(to, "\tbase %q -> %s (%s)\n", base.Base, base.Path, state); err != nil {
+				return err
+			}
+			for i := 0; !base.Exists && i < len(base.Candidates); i++ {
+				if _, err := fmt.Fprintf(to, "\t\ttried %s\n", base.Candidates[i]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, unresolved := range call.Unresolved {
+			if _, err := fmt.Fprintf(to, "%s: warning: cannot resolve base %s: %s\n",
+				unresolved.Position, unresolved.Expression, unresolved.Reason); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func relativePosition(cwd string, position string) string {
+	if cwd == "" {
+		return position
+	}
+	if rel, err := filepath.Rel(cwd, position); err == nil && !filepath.IsAbs(rel) &&
+		rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return position
+}
+
+type ScanFormat string
+
+const (
+	ScanFormatText = ScanFormat("text")
+	ScanFormatJson = ScanFormat("json")
+)
+
+func (instance *ScanFormat) Set(plain string) error {
+	switch ScanFormat(plain) {
+	case ScanFormatText, ScanFormatJson:
+		*instance = ScanFormat(plain)
+		return nil
+	}
+	return fmt.Errorf("unsupported format: %s", plain)
+}
+
+func (instance ScanFormat) String() string {
+	return string(instance)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/echocat/goxr/runtime"
+	"github.com/echocat/goxr/usagescanner"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_NewScanReport(t *testing.T) {
+	calls, err := usagescanner.Scan("../usagescanner/testdata/example", usagescanner.Options{})
+	assert.NoError(t, err)
+	abs, err := filepath.Abs("../usagescanner/testdata/example")
+	assert.NoError(t, err)
+
+	report := NewScanReport(calls)
+	assert.Len(t, report.Calls, 5)
+	assert.Equal(t, "OpenBox", report.Calls[0].Function)
+	assert.Equal(t, filepath.Join(abs, "a.go")+":10:9", report.Calls[0].Position)
+	assert.Len(t, report.Calls[0].Bases, 1)
+	assert.Equal(t, "static", report.Calls[0].Bases[0].Base)
+	assert.Equal(t, filepath.Join(abs, "static"), report.Calls[0].Bases[0].Path)
+	assert.False(t, report.Calls[0].Bases[0].Exists)
+	assert.Contains(t, report.Calls[0].Bases[0].Candidates, filepath.Join(abs, "static"))
+	assert.Equal(t, "OpenBoxBy", report.Calls[3].Function)
+	assert.Len(t, report.Calls[3].Bases, 2)
+	assert.Equal(t, []ScannedUnresolved{{
+		Position:   filepath.Join(abs, "a.go") + ":14:19",
+		Expression: `os.Getenv("BASE")`,
+		Reason:     "not a constant string",
+	}}, report.Calls[4].Unresolved)
+
+	t.Run("text", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, report.WriteText(buf))
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Contains(t, lines, filepath.Join(abs, "a.go")+":10:9: goxr.OpenBox")
+		assert.Contains(t, lines, "\tbase \"static\" -> "+filepath.Join(abs, "static")+" (missing)")
+		assert.Contains(t, lines, "\t\ttried "+filepath.Join(abs, "static"))
+		assert.Contains(t, lines, filepath.Join(abs, "a.go")+`:14:19: warning: cannot resolve base os.Getenv("BASE"): not a constant string`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		command := NewScanCommand()
+		buf := new(bytes.Buffer)
+		command.Directory = "../usagescanner/testdata/example"
+		command.Format = ScanFormatJson
+		command.Output = buf
+		assert.NoError(t, command.ExecuteFromCli(nil))
+
+		var actual ScanReport
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+		assert.Equal(t, report, actual)
+		assert.Contains(t, buf.String(), `"exists": false`)
+		assert.Contains(t, buf.String(), `"unresolved": [`)
+	})
+}
+
+func Test_NewScanReport_boxRoot(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-scan-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "static"), 0755))
+	assert.NoError(t, os.Setenv(runtime.BoxRootEnv, root))
+	defer func() {
+		assert.NoError(t, os.Unsetenv(runtime.BoxRootEnv))
+	}()
+	calls, err := usagescanner.Scan("../usagescanner/testdata/example", usagescanner.Options{})
+	assert.NoError(t, err)
+
+	report := NewScanReport(calls)
+	assert.Equal(t, []ScannedBase{{
+		Base:       "static",
+		Path:       filepath.Join(root, "static"),
+		Exists:     true,
+		Candidates: []string{filepath.Join(root, "static")},
+	}}, report.Calls[0].Bases)
+}
+
+func Test_relativePosition(t *testing.T) {
+	cwd := filepath.FromSlash("/foo/bar")
+	assert.Equal(t, filepath.FromSlash("a/b.go:1:2"), relativePosition(cwd, filepath.FromSlash("/foo/bar/a/b.go:1:2")))
+	assert.Equal(t, filepath.FromSlash(".hidden/b.go:1:2"), relativePosition(cwd, filepath.FromSlash("/foo/bar/.hidden/b.go:1:2")))
+	assert.Equal(t, filepath.FromSlash("..b.go:1:2"), relativePosition(cwd, filepath.FromSlash("/foo/bar/..b.go:1:2")))
+	assert.Equal(t, filepath.FromSlash("/foo/other/b.go:1:2"), relativePosition(cwd, filepath.FromSlash("/foo/other/b.go:1:2")))
+	assert.Equal(t, filepath.FromSlash("/foo/b.go:1:2"), relativePosition("", filepath.FromSlash("/foo/b.go:1:2")))
+}
@@ -0,0 +1,137 @@
+package manifest
+
+import (
+	"fmt"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"regexp"
+	"strings"
+)
+
+type Filter struct {
+	Includes []string `yaml:"includes,omitempty"`
+	Excludes []string `yaml:"excludes,omitempty"`
+}
+
+func (instance Filter) predicate(field string) (common.FilePredicate, error) {
+	includes, err := compilePatterns(field+".includes", instance.Includes)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compilePatterns(field+".excludes", instance.Excludes)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) (bool, error) {
+		if len(includes) > 0 && !matchesAny(includes, name) {
+			return false, nil
+		}
+		if matchesAny(excludes, name) {
+			return false, nil
+		}
+		return true, nil
+	}, nil
+}
+
+type EntryRule struct {
+	Pattern string     `yaml:"pattern"`
+	Meta    entry.Meta `yaml:"meta,omitempty"`
+}
+
+func (instance EntryRule) matcher(index int) (entryMatcher, error) {
+	if instance.Pattern == "" {
+		return entryMatcher{}, fmt.Errorf(`entries[%d].pattern - missing`, index)
+	} else if r, err := regexp.Compile(instance.Pattern); err != nil {
+		return entryMatcher{}, fmt.Errorf(`entries[%d].pattern = "%s" - pattern invalid: %v`, index, instance.Pattern, err)
+	} else {
+		return entryMatcher{r, instance}, nil
+	}
+}
+
+type entryMatcher struct {
+	pattern *regexp.Regexp
+	rule    EntryRule
+}
+
+func (instance entryMatcher) applyTo(target *packed.TargetEntry) {
+	if !instance.pattern.MatchString(target.Filename) {
+		return
+	}
+	for k, v := range instance.rule.Meta {
+		target.Meta[k] = v
+	}
+}
+
+type TransformRule struct {
+	Pattern      string   `yaml:"pattern"`
+	Transformers []string `yaml:"transformers"`
+}
+
+// ParseTransformRule parses rules of the format <regexp>=<transformer>[,<transformer>...]
+func ParseTransformRule(plain string) (TransformRule, error) {
+	i := strings.LastIndexByte(plain, '=')
+	if i <= 0 || i == len(plain)-1 {
+		return TransformRule{}, fmt.Errorf(`illegal transform rule "%s" - expected format is <regexp>=<transformer>[,<transformer>...]`, plain)
+	}
+	return TransformRule{
+		Pattern:      plain[:i],
+		Transformers: strings.Split(plain[i+1:], ","),
+	}, nil
+}
+
+func (instance TransformRule) matcher(index int, box *packed.Box) (transformMatcher, error) {
+	result := transformMatcher{
+		transformers: make([]packed.ContentTransformer, len(instance.Transformers)),
+	}
+	if instance.Pattern == "" {
+		return transformMatcher{}, fmt.Errorf(`transform[%d].pattern - missing`, index)
+	} else if r, err := regexp.Compile(instance.Pattern); err != nil {
+		return transformMatcher{}, fmt.Errorf(`transform[%d].pattern = "%s" - pattern invalid: %v`, index, instance.Pattern, err)
+	} else {
+		result.pattern = r
+	}
+	if len(instance.Transformers) == 0 {
+		return transformMatcher{}, fmt.Errorf(`transform[%d].transformers - missing`, index)
+	}
+	for i, name := range instance.Transformers {
+		if t, err := packed.NewContentTransformer(strings.TrimSpace(name), box); err != nil {
+			return transformMatcher{}, fmt.Errorf(`transform[%d].transformers[%d] - %v`, index, i, err)
+		} else {
+			result.transformers[i] = t
+		}
+	}
+	return result, nil
+}
+
+type transformMatcher struct {
+	pattern      *regexp.Regexp
+	transformers []packed.ContentTransformer
+}
+
+func (instance transformMatcher) applyTo(target *packed.TargetEntry) {
+	if instance.pattern.MatchString(target.Filename) {
+		target.Transformers = append(target.Transformers, instance.transformers...)
+	}
+}
+
+func compilePatterns(field string, patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		if r, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf(`%s[%d] = "%s" - pattern invalid: %v`, field, i, pattern, err)
+		} else {
+			result[i] = r
+		}
+	}
+	return result, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, candidate string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
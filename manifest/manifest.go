@@ -0,0 +1,229 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"github.com/echocat/goxr/server/configuration"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DefaultFilename = `goxr.yaml`
+
+func OfFile(filename string) (m Manifest, rErr error) {
+	if f, err := os.Open(filename); err != nil {
+		return Manifest{}, common.NewPathError("readManifest", filename, err)
+	} else {
+		defer func() {
+			if err := f.Close(); err != nil {
+				rErr = err
+			}
+		}()
+		if m, err := Read(f); err != nil {
+			return Manifest{}, common.NewPathError("readManifest", filename, err)
+		} else if dir, err := filepath.Abs(filepath.Dir(filename)); err != nil {
+			return Manifest{}, common.NewPathError("readManifest", filename, err)
+		} else {
+			return m.resolveRelativeTo(dir), nil
+		}
+	}
+}
+
+func Read(reader io.Reader) (m Manifest, err error) {
+	decoder := yaml.NewDecoder(reader)
+	decoder.SetStrict(true)
+	err = decoder.Decode(&m)
+	return
+}
+
+type Manifest struct {
+	Name        string                       `yaml:"name,omitempty"`
+	Version     string                       `yaml:"version,omitempty"`
+	Description string                       `yaml:"description,omitempty"`
+	Revision    string                       `yaml:"revision,omitempty"`
+	Built       *time.Time                   `yaml:"built,omitempty"`
+	Output      string                       `yaml:"output,omitempty"`
+	Bases       []Base                       `yaml:"bases,omitempty"`
+	Filter      Filter                       `yaml:"filter,omitempty"`
+	Entries     []EntryRule                  `yaml:"entries,omitempty"`
+	Fingerprint []string                     `yaml:"fingerprint,omitempty"`
+	Transform   []TransformRule              `yaml:"transform,omitempty"`
+	Policy      packed.Policy                `yaml:"policy,omitempty"`
+	Symlinks    packed.SymlinkPolicy         `yaml:"symlinks,omitempty"`
+	Precompress []common.Encoding            `yaml:"precompress,omitempty"`
+	Server      *configuration.Configuration `yaml:"server,omitempty"`
+}
+
+func (instance Manifest) resolveRelativeTo(dir string) Manifest {
+	result := instance
+	if result.Output != "" && !filepath.IsAbs(result.Output) {
+		result.Output = filepath.Join(dir, filepath.FromSlash(result.Output))
+	}
+	result.Bases = make([]Base, len(instance.Bases))
+	for i, base := range instance.Bases {
+		if base.Path != "" && !filepath.IsAbs(base.Path) {
+			base.Path = filepath.Join(dir, filepath.FromSlash(base.Path))
+		}
+		result.Bases[i] = base
+	}
+	return result
+}
+
+func (instance Manifest) Validate() error {
+	var errs []error
+	for i, base := range instance.Bases {
+		if base.Path == "" {
+			errs = append(errs, fmt.Errorf(`bases[%d].path - missing`, i))
+		}
+		if _, err := base.Filter.predicate(fmt.Sprintf("bases[%d]", i)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := instance.Filter.predicate("filter"); err != nil {
+		errs = append(errs, err)
+	}
+	for i, rule := range instance.Entries {
+		if _, err := rule.matcher(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := compilePatterns("fingerprint", instance.Fingerprint); err != nil {
+		errs = append(errs, err)
+	}
+	for i, rule := range instance.Transform {
+		if _, err := rule.matcher(i, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := instance.Policy.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("policy.%v", err))
+	}
+	for i, encoding := range instance.Precompress {
+		if _, err := common.ParseEncoding(string(encoding)); err != nil {
+			errs = append(errs, fmt.Errorf(`precompress[%d] = "%s" - %v`, i, encoding, err))
+		}
+	}
+
+	if len(errs) <= 0 {
+		return nil
+	} else if len(errs) == 1 {
+		return fmt.Errorf("manifest invalid: %v", errs[0])
+	}
+	buf := new(bytes.Buffer)
+	common.MustWritef(buf, "manifest invalid:")
+	for i, err := range errs {
+		common.MustWritef(buf, "\n  %d. %v", i+1, err)
+	}
+	return errors.New(buf.String())
+}
+
+func (instance Manifest) ApplyTo(box *packed.Box) {
+	box.Name = instance.Name
+	box.Version = instance.Version
+	box.Description = instance.Description
+	if instance.Revision != "" {
+		box.Revision = instance.Revision
+	}
+	if instance.Built != nil {
+		box.Built = *instance.Built
+	}
+}
+
+func (instance Manifest) WriteServerConfiguration(writer *packed.Writer) error {
+	if instance.Server == nil {
+		return nil
+	}
+	if b, err := yaml.Marshal(instance.Server); err != nil {
+		return common.NewPathError("writeServerConfiguration", configuration.LocationInBox, err)
+	} else {
+		return writer.Write(packed.TargetEntry{
+			Filename: configuration.LocationInBox,
+		}, bytes.NewReader(b))
+	}
+}
+
+// WriteBase writes the files of the base with the given index of Bases.
+func (instance Manifest) WriteBase(writer *packed.Writer, i int, interceptor packed.WriteFilesInterceptor) error {
+	base := instance.Bases[i]
+	global, err := instance.Filter.predicate("filter")
+	if err != nil {
+		return err
+	}
+	local, err := base.Filter.predicate(fmt.Sprintf("bases[%d]", i))
+	if err != nil {
+		return err
+	}
+	rules := make([]entryMatcher, len(instance.Entries))
+	for i, rule := range instance.Entries {
+		if rules[i], err = rule.matcher(i); err != nil {
+			return err
+		}
+	}
+	fingerprint, err := compilePatterns("fingerprint", instance.Fingerprint)
+	if err != nil {
+		return err
+	}
+	transforms := make([]transformMatcher, len(instance.Transform))
+	for i, rule := range instance.Transform {
+		if transforms[i], err = rule.matcher(i, writer.Box()); err != nil {
+			return err
+		}
+	}
+
+	return writer.WriteFilesRecursiveWithPrefix(base.Prefix, base.Path, func(candidate *packed.WriteCandidate) error {
+		target := candidate.Target
+		if ok, err := global(target.Filename); err != nil {
+			return err
+		} else if !ok {
+			candidate.Accept = false
+			return nil
+		} else if ok, err := local(target.Filename); err != nil {
+			return err
+		} else if !ok {
+			candidate.Accept = false
+			return nil
+		}
+		for _, rule := range rules {
+			rule.applyTo(target)
+		}
+		if matchesAny(fingerprint, target.Filename) {
+			target.Fingerprint = true
+		}
+		for _, transform := range transforms {
+			transform.applyTo(target)
+		}
+		if interceptor != nil {
+			return interceptor(candidate)
+		}
+		return nil
+	})
+}
+
+type Base struct {
+	Path   string `yaml:"path"`
+	Prefix string `yaml:"prefix,omitempty"`
+	Filter `yaml:",inline"`
+}
+
+func ParseBase(plain string) Base {
+	parts := strings.SplitN(plain, "=", 2)
+	if len(parts) > 1 {
+		return Base{Prefix: parts[0], Path: parts[1]}
+	}
+	return Base{Path: plain}
+}
+
+func (instance Base) String() string {
+	if instance.Prefix != "" {
+		return entry.CleanPath(instance.Prefix) + "=" + instance.Path
+	}
+	return instance.Path
+}
@@ -0,0 +1,138 @@
+package manifest
+
+import (
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/server/configuration"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func Test_OfFile(t *testing.T) {
+	dir := tempDirForT(t)
+	defer func() { _ = os.RemoveAll(dir) }()
+	writeFileForT(t, filepath.Join(dir, DefaultFilename), `
+name: test
+version: 1.2.3
+output: dist/test.box
+bases:
+  - path: "resources/a=b"
+    prefix: static
+    excludes: ['\.map$']
+`)
+
+	m, err := OfFile(filepath.Join(dir, DefaultFilename))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Validate())
+	assert.Equal(t, "test", m.Name)
+	assert.Equal(t, "1.2.3", m.Version)
+	assert.Equal(t, filepath.Join(dir, "dist", "test.box"), m.Output)
+	assert.Equal(t, []Base{{
+		Path:   filepath.Join(dir, "resources", "a=b"),
+		Prefix: "static",
+		Filter: Filter{Excludes: []string{`\.map$`}},
+	}}, m.Bases)
+}
+
+func Test_Manifest_Validate(t *testing.T) {
+	m, err := Read(strings.NewReader(`
+bases:
+  - prefix: foo
+filter:
+  includes: ['[']
+entries:
+  - pattern: ""
+`))
+	assert.NoError(t, err)
+	err = m.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bases[0].path - missing")
+	assert.Contains(t, err.Error(), "filter.includes[0]")
+	assert.Contains(t, err.Error(), "entries[0].pattern - missing")
+}
+
+func Test_Manifest_WriteBase(t *testing.T) {
+	dir := tempDirForT(t)
+	defer func() { _ = os.RemoveAll(dir) }()
+	writeFileForT(t, filepath.Join(dir, "base", "index.html"), "<html></html>")
+	writeFileForT(t, filepath.Join(dir, "base", "app.js"), "alert(1)")
+	writeFileForT(t, filepath.Join(dir, "base", "app.js.map"), "{}")
+
+	index := "/static/index.html"
+	m := Manifest{
+		Name: "test",
+		Bases: []Base{{
+			Path:   filepath.Join(dir, "base"),
+			Prefix: "static",
+			Filter: Filter{Excludes: []string{`\.map$`}},
+		}},
+		Entries: []EntryRule{{
+			Pattern: `\.js$`,
+			Meta:    map[string]interface{}{"kind": "script"},
+		}},
+		Server: &configuration.Configuration{
+			Paths: configuration.Paths{Index: &index},
+		},
+	}
+
+	target := filepath.Join(dir, "test.box")
+	writer, err := packed.NewWriter(target, packed.OpenModeCreateOnly, packed.WriteModeNewOnly)
+	assert.NoError(t, err)
+	m.ApplyTo(writer.Box())
+	assert.NoError(t, m.WriteServerConfiguration(writer))
+	for i := range m.Bases {
+		assert.NoError(t, m.WriteBase(writer, i, nil))
+	}
+	assert.NoError(t, writer.Close())
+
+	box, err := packed.OpenBox(target)
+	assert.NoError(t, err)
+	defer func() { _ = box.Close() }()
+
+	var names []string
+	for name := range box.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{configuration.LocationInBox, "static/app.js", "static/index.html"}, names)
+	assert.Equal(t, "test", box.Name)
+	assert.Equal(t, "script", box.Entries["static/app.js"].Meta["kind"])
+	assert.Nil(t, box.Entries["static/index.html"].Meta["kind"])
+
+	c, err := configuration.OfBox(box)
+	assert.NoError(t, err)
+	assert.Equal(t, index, c.Paths.GetIndex())
+}
+
+func tempDirForT(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "goxr-manifest-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFileForT(t *testing.T, filename string, content string) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Manifest_WriteBase_illegalFilter(t *testing.T) {
+	m := Manifest{
+		Bases: []Base{{Path: "."}, {
+			Path:   ".",
+			Filter: Filter{Excludes: []string{`(`}},
+		}},
+	}
+	err := m.WriteBase(nil, 1, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bases[1].excludes")
+}
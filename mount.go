@@ -0,0 +1,331 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Mount struct {
+	Prefix string
+	Box    Box
+}
+
+// ParseMount parses mounts of the format <prefix>=<box> and returns the prefix
+// and the box part.
+func ParseMount(plain string) (prefix string, box string, err error) {
+	parts := strings.SplitN(plain, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", common.NewPathError("parseMount", plain, os.ErrInvalid)
+	}
+	return cleanMountPrefix(parts[0]), parts[1], nil
+}
+
+func (instance Mount) prefix() string {
+	return cleanMountPrefix(instance.Prefix)
+}
+
+func (instance Mount) relative(name string) (string, bool) {
+	prefix := instance.prefix()
+	if prefix == "" {
+		return name, true
+	} else if name == prefix {
+		return "", true
+	} else if strings.HasPrefix(name, prefix+"/") {
+		return name[len(prefix)+1:], true
+	}
+	return "", false
+}
+
+func cleanMountPrefix(prefix string) string {
+	result := strings.Trim(entry.CleanPath(prefix), "/")
+	if result == "." {
+		return ""
+	}
+	return result
+}
+
+// MountBox mounts several boxes under different path prefixes. If the prefixes
+// of mounts are overlapping the mount with the longest matching prefix wins.
+// All parent directories of the prefixes are available as directories.
+type MountBox []Mount
+
+func (instance MountBox) resolve(name string) (Mount, string, bool) {
+	var result Mount
+	var relative string
+	found := false
+	for _, mount := range instance {
+		if candidate, ok := mount.relative(name); ok && (!found || len(mount.prefix()) > len(result.prefix())) {
+			result, relative, found = mount, candidate, true
+		}
+	}
+	return result, relative, found
+}
+
+// isSyntheticDir returns true if the given name is a prefix or a parent
+// directory of a prefix of at least one mount.
+func (instance MountBox) isSyntheticDir(name string) bool {
+	for _, mount := range instance {
+		prefix := mount.prefix()
+		if name == "" || name == prefix || strings.HasPrefix(prefix, name+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (instance MountBox) Open(name string) (common.File, error) {
+	cleaned := cleanMountPrefix(name)
+	if mount, relative, ok := instance.resolve(cleaned); ok {
+		if f, err := mount.Box.Open(relative); err == nil {
+			return &mountedFile{File: f, prefix: mount.prefix(), path: cleaned}, nil
+		} else if !os.IsNotExist(err) || !instance.isSyntheticDir(cleaned) {
+			return nil, err
+		}
+	}
+	if instance.isSyntheticDir(cleaned) {
+		return &mountDir{box: instance, info: mountDirInfo(cleaned)}, nil
+	}
+	return nil, common.NewPathError("open", name, os.ErrNotExist)
+}
+
+func (instance MountBox) Info(name string) (common.FileInfo, error) {
+	cleaned := cleanMountPrefix(name)
+	if mount, relative, ok := instance.resolve(cleaned); ok {
+		if fi, err := mount.Box.Info(relative); err == nil {
+			return mountedFileInfo{fi, cleaned}, nil
+		} else if !os.IsNotExist(err) || !instance.isSyntheticDir(cleaned) {
+			return nil, err
+		}
+	}
+	if instance.isSyntheticDir(cleaned) {
+		return mountDirInfo(cleaned), nil
+	}
+	return nil, common.NewPathError("info", name, os.ErrNotExist)
+}
+
+// ForEach reports the files of all mounts which are Iterable with their
+// paths inside of this box. Files which are shadowed by mounts with a longer
+// prefix are not reported.
+func (instance MountBox) ForEach(predicate common.FilePredicate, callback func(common.FileInfo) error) error {
+	for _, mount := range instance {
+		ib, ok := mount.Box.(Iterable)
+		if !ok {
+			continue
+		}
+		prefix := mount.prefix()
+		if err := ib.ForEach(nil, func(fi common.FileInfo) error {
+			p := path.Join(prefix, entry.CleanPath(fi.Path()))
+			if owner, _, _ := instance.resolve(p); owner.prefix() != prefix {
+				return nil
+			}
+			if predicate != nil {
+				if ok, err := predicate(p); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+			}
+			return callback(mountedFileInfo{fi, p})
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (instance MountBox) Watch(listener common.ChangeListener) (io.Closer, error) {
+	var closers []io.Closer
+	for _, mount := range instance {
+		wb, ok := mount.Box.(Watchable)
+		if !ok {
+			continue
+		}
+		prefix := mount.prefix()
+		if closer, err := wb.Watch(func(event common.ChangeEvent) {
+			event.Path = path.Join(prefix, entry.CleanPath(event.Path))
+			listener(event)
+		}); err != nil {
+			_ = closeAll(closers)
+			return nil, err
+		} else {
+			closers = append(closers, closer)
+		}
+	}
+	if len(closers) == 0 {
+		return nil, ErrBoxWatchNotSupported
+	}
+	return common.NewOnceCloser(func() error {
+		return closeAll(closers)
+	}), nil
+}
+
+func (instance MountBox) Close() error {
+	closers := make([]io.Closer, len(instance))
+	for i, mount := range instance {
+		closers[i] = mount.Box
+	}
+	return closeAll(closers)
+}
+
+func (instance MountBox) With(prefix string, box Box) MountBox {
+	return append(instance, Mount{Prefix: prefix, Box: box})
+}
+
+func (instance MountBox) readdir(dir string) ([]os.FileInfo, error) {
+	children := make(map[string]os.FileInfo)
+	addDir := func(p string) {
+		if _, ok := children[p]; !ok {
+			children[p] = mountDirInfo(p)
+		}
+	}
+	collect := func(p string, fi os.FileInfo) {
+		relative := p
+		if dir != "" {
+			if !strings.HasPrefix(p, dir+"/") {
+				return
+			}
+			relative = p[len(dir)+1:]
+		}
+		if i := strings.IndexByte(relative, '/'); i >= 0 {
+			addDir(path.Join(dir, relative[:i]))
+		} else if fi != nil {
+			children[p] = fi
+		} else {
+			addDir(p)
+		}
+	}
+
+	for _, mount := range instance {
+		if prefix := mount.prefix(); prefix != "" {
+			collect(prefix, nil)
+		}
+	}
+	if err := instance.ForEach(nil, func(fi common.FileInfo) error {
+		collect(fi.Path(), fi)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	result := make([]os.FileInfo, 0, len(children))
+	for _, fi := range children {
+		result = append(result, fi)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result, nil
+}
+
+// mountedFile reports the infos of itself and its children with their paths
+// inside of the MountBox. The info of itself carries the path reported by the
+// mounted box, so links resolved by it stay visible.
+type mountedFile struct {
+	common.File
+	prefix string
+	path   string
+}
+
+func (instance *mountedFile) GetFileInfo() (common.FileInfo, error) {
+	if fi, err := instance.File.GetFileInfo(); err != nil {
+		return nil, err
+	} else {
+		return mountedFileInfo{fi, path.Join(instance.prefix, entry.CleanPath(fi.Path()))}, nil
+	}
+}
+
+func (instance *mountedFile) Stat() (os.FileInfo, error) {
+	return instance.GetFileInfo()
+}
+
+func (instance *mountedFile) Readdir(count int) ([]os.FileInfo, error) {
+	result, err := instance.File.Readdir(count)
+	for i, fi := range result {
+		result[i] = mountedFileInfo{fi, path.Join(instance.path, fi.Name())}
+	}
+	return result, err
+}
+
+type mountedFileInfo struct {
+	os.FileInfo
+	path string
+}
+
+func (instance mountedFileInfo) Path() string {
+	return instance.path
+}
+
+func (instance mountedFileInfo) ChecksumString() string {
+	if efi, ok := instance.FileInfo.(common.ExtendedFileInfo); ok {
+		return efi.ChecksumString()
+	}
+	return ""
+}
+
+type mountDirInfo string
+
+func (instance mountDirInfo) Name() string {
+	if instance == "" {
+		return "/"
+	}
+	return path.Base(string(instance))
+}
+
+func (instance mountDirInfo) Path() string       { return string(instance) }
+func (instance mountDirInfo) Size() int64        { return 0 }
+func (instance mountDirInfo) Mode() os.FileMode  { return os.ModeDir | 0755 }
+func (instance mountDirInfo) ModTime() time.Time { return time.Time{} }
+func (instance mountDirInfo) IsDir() bool        { return true }
+func (instance mountDirInfo) Sys() interface{}   { return nil }
+
+type mountDir struct {
+	box    MountBox
+	info   mountDirInfo
+	offset int
+}
+
+func (instance *mountDir) Close() error {
+	return nil
+}
+
+func (instance *mountDir) Read([]byte) (int, error) {
+	return 0, common.NewPathError("read", instance.info.Path(), os.ErrInvalid)
+}
+
+func (instance *mountDir) Seek(int64, int) (int64, error) {
+	return 0, common.NewPathError("seek", instance.info.Path(), os.ErrInvalid)
+}
+
+func (instance *mountDir) Readdir(count int) ([]os.FileInfo, error) {
+	all, err := instance.box.readdir(instance.info.Path())
+	if err != nil {
+		return nil, err
+	}
+	all = all[instance.offset:]
+	if count <= 0 {
+		instance.offset += len(all)
+		return all, nil
+	}
+	if len(all) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(all) {
+		count = len(all)
+	}
+	instance.offset += count
+	return all[:count], nil
+}
+
+func (instance *mountDir) Stat() (os.FileInfo, error) {
+	return instance.info, nil
+}
+
+func (instance *mountDir) GetFileInfo() (common.FileInfo, error) {
+	return instance.info, nil
+}
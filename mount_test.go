@@ -0,0 +1,113 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+)
+
+func Test_MountBox(t *testing.T) {
+	rootDir := tempDirWithFilesForT(t, map[string]string{
+		"index.html":    "index",
+		"docs/old.html": "old docs",
+	})
+	defer removeAllForT(rootDir, t)
+	docsDir := tempDirWithFilesForT(t, map[string]string{
+		"index.html":   "docs",
+		"api/foo.html": "foo",
+	})
+	defer removeAllForT(docsDir, t)
+	appDir := tempDirWithFilesForT(t, map[string]string{
+		"app.js": "app",
+	})
+	defer removeAllForT(appDir, t)
+
+	root, err := fs.OpenBox(rootDir)
+	assert.NoError(t, err)
+	docs, err := fs.OpenBox(docsDir)
+	assert.NoError(t, err)
+	app, err := fs.OpenBox(appDir)
+	assert.NoError(t, err)
+
+	box := MountBox{}.
+		With("", root).
+		With("/docs", docs).
+		With("/static/app/", app)
+
+	contentOf := func(name string) string {
+		f, err := box.Open(name)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer func() {
+			assert.NoError(t, f.Close())
+		}()
+		b, err := ioutil.ReadAll(f)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "index", contentOf("/index.html"))
+	assert.Equal(t, "docs", contentOf("/docs/index.html"))
+	assert.Equal(t, "foo", contentOf("docs/api/foo.html"))
+	assert.Equal(t, "app", contentOf("/static/app/app.js"))
+
+	_, err = box.Open("/docs/old.html")
+	assert.True(t, os.IsNotExist(err))
+
+	fi, err := box.Info("/static")
+	assert.NoError(t, err)
+	assert.True(t, fi.IsDir())
+
+	fi, err = box.Info("/docs/api/foo.html")
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/api/foo.html", fi.Path())
+
+	f, err := box.Open("/docs/api/foo.html")
+	assert.NoError(t, err)
+	fi, err = f.GetFileInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "docs/api/foo.html", fi.Path())
+	assert.NotEmpty(t, fi.(common.ExtendedFileInfo).ChecksumString())
+	assert.NoError(t, f.Close())
+
+	f, err = box.Open("/docs/api")
+	assert.NoError(t, err)
+	children, err := f.Readdir(-1)
+	assert.NoError(t, err)
+	if assert.Len(t, children, 1) {
+		assert.Equal(t, "docs/api/foo.html", children[0].(common.FileInfo).Path())
+	}
+	assert.NoError(t, f.Close())
+
+	var paths []string
+	assert.NoError(t, box.ForEach(nil, func(fi common.FileInfo) error {
+		paths = append(paths, fi.Path())
+		return nil
+	}))
+	sort.Strings(paths)
+	assert.Equal(t, []string{"docs/api/foo.html", "docs/index.html", "index.html", "static/app/app.js"}, paths)
+
+	dir, err := box.Open("/static")
+	assert.NoError(t, err)
+	fis, err := dir.Readdir(-1)
+	assert.NoError(t, err)
+	if assert.Len(t, fis, 1) {
+		assert.Equal(t, "app", fis[0].Name())
+		assert.True(t, fis[0].IsDir())
+	}
+}
+
+func Test_ParseMount(t *testing.T) {
+	prefix, box, err := ParseMount("/docs/=./docs.box")
+	assert.NoError(t, err)
+	assert.Equal(t, "docs", prefix)
+	assert.Equal(t, "./docs.box", box)
+
+	_, _, err = ParseMount("docs")
+	assert.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package runtime
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	rs "runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// BoxRootEnv is the environment variable which overrides the directory
+// relative bases of boxes are resolved against.
+const BoxRootEnv = "GOXR_BOX_ROOT"
+
+// Caller describes the source location which requests a box.
+type Caller struct {
+	// Filename of the source file. In case of -trimpath builds this is not
+	// an absolute path but the module path followed by the path inside the module.
+	Filename string
+	// Package is the import path of the package of the caller.
+	Package string
+}
+
+// CallerOutsideOf returns the first caller on the stack which is not part of
+// the given package (import path).
+func CallerOutsideOf(pkg string) (Caller, bool) {
+	pcs := make([]uintptr, 32)
+	frames := rs.CallersFrames(pcs[:rs.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if p := packageOfFunction(frame.Function); p != "" && p != pkg {
+			return Caller{
+				// this little hack courtesy of the `-cover` flag!!
+				Filename: strings.Replace(frame.File, "/"+path.Join("_test", "_obj_test"), "", 1),
+				Package:  p,
+			}, true
+		}
+		if !more {
+			return Caller{}, false
+		}
+	}
+}
+
+// packageOfFunction extracts the import path of the package of the given
+// fully qualified function name like "github.com/foo/bar.(*Type).Method".
+func packageOfFunction(function string) string {
+	lastSlash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[lastSlash+1:], '.'); dot >= 0 {
+		return function[:lastSlash+1+dot]
+	}
+	return ""
+}
+
+// BaseNotFoundError is returned by ResolveBase if none of the candidates exist.
+type BaseNotFoundError struct {
+	Base  string
+	Tried []string
+}
+
+func (instance BaseNotFoundError) Error() string {
+	if len(instance.Tried) == 0 {
+		return fmt.Sprintf("cannot find base '%s' of box: there is no directory to resolve it against; set %s to the directory which contains it",
+			instance.Base, BoxRootEnv)
+	}
+	return fmt.Sprintf("cannot find base '%s' of box; tried:\n\t%s\nset %s to the directory which contains it",
+		instance.Base, strings.Join(instance.Tried, "\n\t"), BoxRootEnv)
+}
+
+// ResolveBase resolves the given relative base of a box requested by the given
+// caller. The first existing candidate of BaseCandidates will be returned.
+func ResolveBase(base string, caller Caller) (string, error) {
+	candidates := BaseCandidates(base, caller)
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", BaseNotFoundError{
+		Base:  base,
+		Tried: candidates,
+	}
+}
+
+// BaseCandidates returns all locations where the given relative base of a box
+// requested by the given caller could be located. This is in order:
+//
+//  1. If set only the directory of BoxRootEnv.
+//  2. The directory of the source file of the caller (if absolute).
+//  3. The package directory inside of the main module. The root of the module
+//     is located by searching for its go.mod starting at the working directory
+//     and the directory of the executable. For callers in package main (which
+//     has no import path) the directory is taken from the -trimpath filename.
+//  4. The package directory inside of the module cache (for dependencies).
+//  5. The package directory inside of $GOPATH/src.
+func BaseCandidates(base string, caller Caller) []string {
+	if root := os.Getenv(BoxRootEnv); root != "" {
+		return []string{filepath.Join(root, base)}
+	}
+
+	var result []string
+	add := func(candidate string) {
+		for _, existing := range result {
+			if existing == candidate {
+				return
+			}
+		}
+		result = append(result, candidate)
+	}
+
+	dir := path.Dir(caller.Filename)
+	if caller.Filename != "" && filepath.IsAbs(filepath.FromSlash(dir)) {
+		add(filepath.Join(filepath.FromSlash(dir), base))
+	}
+	if modulePath, ok := MainModulePath(); ok {
+		if relative, ok := directoryInsideOfModule(caller, modulePath); ok {
+			for _, root := range ModuleRoots(modulePath) {
+				add(filepath.Join(root, filepath.FromSlash(relative), base))
+			}
+		}
+	}
+	if caller.Filename != "" && !filepath.IsAbs(filepath.FromSlash(dir)) {
+		if strings.ContainsRune(dir, '@') {
+			if modCache := ModuleCache(); modCache != "" {
+				add(filepath.Join(modCache, filepath.FromSlash(dir), base))
+			}
+		}
+		if goPath := GoPath(); goPath != "" {
+			add(filepath.Join(goPath, "src", filepath.FromSlash(dir), base))
+		}
+	}
+	return result
+}
+
+func directoryInsideOfModule(caller Caller, modulePath string) (string, bool) {
+	if caller.Package != "" && caller.Package != "main" {
+		if relative, ok := packageInsideOfModule(caller.Package, modulePath); ok {
+			return relative, true
+		}
+	}
+	if dir := path.Dir(caller.Filename); caller.Filename != "" && !filepath.IsAbs(filepath.FromSlash(dir)) {
+		return packageInsideOfModule(dir, modulePath)
+	}
+	return "", false
+}
+
+func packageInsideOfModule(pkg, modulePath string) (string, bool) {
+	if pkg == modulePath {
+		return "", true
+	} else if strings.HasPrefix(pkg, modulePath+"/") {
+		return pkg[len(modulePath)+1:], true
+	}
+	return "", false
+}
+
+// MainModulePath returns the path of the main module this executable was built from.
+func MainModulePath() (string, bool) {
+	if info, ok := debug.ReadBuildInfo(); !ok || info.Main.Path == "" {
+		return "", false
+	} else {
+		return info.Main.Path, true
+	}
+}
+
+// ModuleRoots returns all directories which contains a go.mod of the given
+// module. It is searched upwards starting at the working directory and the
+// directory of the executable.
+func ModuleRoots(modulePath string) []string {
+	var starts []string
+	if wd, err := os.Getwd(); err == nil {
+		starts = append(starts, wd)
+	}
+	if executable, err := os.Executable(); err == nil {
+		starts = append(starts, filepath.Dir(executable))
+	}
+
+	var result []string
+	for _, start := range starts {
+		if root, ok := findModuleRoot(start, modulePath); ok {
+			duplicate := false
+			for _, existing := range result {
+				duplicate = duplicate || existing == root
+			}
+			if !duplicate {
+				result = append(result, root)
+			}
+		}
+	}
+	return result
+}
+
+func findModuleRoot(start string, modulePath string) (string, bool) {
+	for dir := start; ; {
+		if candidate, ok := moduleOf(filepath.Join(dir, "go.mod")); ok && candidate == modulePath {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func moduleOf(goModFilename string) (string, bool) {
+	f, err := os.Open(goModFilename)
+	if err != nil {
+		return "", false
+	}
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+				return unquoted, true
+			}
+			return fields[1], true
+		}
+	}
+	return "", false
+}
+
+// ModuleCache returns the location of the module cache.
+func ModuleCache() string {
+	if v := os.Getenv("GOMODCACHE"); v != "" {
+		return v
+	}
+	if goPath := GoPath(); goPath != "" {
+		return filepath.Join(filepath.SplitList(goPath)[0], "pkg", "mod")
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_packageOfFunction(t *testing.T) {
+	assert.Equal(t, "github.com/echocat/goxr", packageOfFunction("github.com/echocat/goxr.OpenBox"))
+	assert.Equal(t, "github.com/echocat/goxr/box/fs", packageOfFunction("github.com/echocat/goxr/box/fs.(*Box).Open"))
+	assert.Equal(t, "main", packageOfFunction("main.main.func1"))
+	assert.Equal(t, "", packageOfFunction(""))
+}
+
+func Test_CallerOutsideOf(t *testing.T) {
+	caller, ok := CallerOutsideOf("github.com/echocat/goxr/runtime")
+	assert.True(t, ok)
+	assert.Equal(t, "testing", caller.Package)
+}
+
+func Test_ResolveBase(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-bases-")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "pkg", "resources"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module \"example.com/foo\"\n\ngo 1.13\n"), 0644))
+
+	t.Run("callerDirectory", func(t *testing.T) {
+		actual, err := ResolveBase("resources", Caller{Filename: filepath.ToSlash(filepath.Join(root, "pkg", "main.go"))})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "pkg", "resources"), actual)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		assert.NoError(t, os.Setenv(BoxRootEnv, filepath.Join(root, "pkg")))
+		defer func() {
+			assert.NoError(t, os.Unsetenv(BoxRootEnv))
+		}()
+		actual, err := ResolveBase("resources", Caller{Filename: "example.com/foo/pkg/main.go", Package: "example.com/foo/pkg"})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "pkg", "resources"), actual)
+	})
+
+	t.Run("mainPackageInsideOfModule", func(t *testing.T) {
+		modulePath, ok := MainModulePath()
+		assert.True(t, ok)
+		base, err := ioutil.TempDir(".", "goxr-bases-")
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, os.RemoveAll(base))
+		}()
+		wd, err := os.Getwd()
+		assert.NoError(t, err)
+
+		actual, err := ResolveBase(base, Caller{Filename: modulePath + "/runtime/main.go", Package: "main"})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(wd, base), actual)
+	})
+
+	t.Run("notFound", func(t *testing.T) {
+		_, err := ResolveBase("resources", Caller{Filename: filepath.ToSlash(filepath.Join(root, "other", "main.go"))})
+		assert.IsType(t, BaseNotFoundError{}, err)
+		assert.Contains(t, err.Error(), filepath.Join(root, "other", "resources"))
+		assert.Contains(t, err.Error(), BoxRootEnv)
+	})
+
+	t.Run("moduleRoot", func(t *testing.T) {
+		actual, ok := findModuleRoot(filepath.Join(root, "pkg", "resources"), "example.com/foo")
+		assert.True(t, ok)
+		assert.Equal(t, root, actual)
+
+		_, ok = findModuleRoot(filepath.Join(root, "pkg"), "example.com/bar")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/valyala/fasthttp"
+	"io"
+	"mime"
+	sPath "path"
+	"strings"
+	"sync"
+)
+
+// NegotiateEncoding selects the representation of the given file which is sent
+// to the client. A precompressed sibling is preferred (see
+// NegotiatePrecompressed), otherwise the file might be compressed on the fly
+// (see NegotiateCompression).
+func (instance *Server) NegotiateEncoding(box goxr.Box, path string, f common.File, fi common.FileInfo, ctx *fasthttp.RequestCtx) (common.File, common.FileInfo) {
+	f, fi = instance.NegotiatePrecompressed(box, path, f, fi, ctx)
+	return f, instance.NegotiateCompression(fi, ctx)
+}
+
+// NegotiateCompression returns a FileInfo which marks the file to be
+// compressed on the fly if response.compression applies to it and the client
+// accepts one of the configured algorithms. In this case Content-Encoding is
+// set. Requests for ranges are never compressed on the fly, because the
+// compressed content is not known in advance. Precompressed siblings (see
+// NegotiatePrecompressed) are different: ranges of them are served as ranges
+// of the encoded representation.
+func (instance *Server) NegotiateCompression(fi common.FileInfo, ctx *fasthttp.RequestCtx) common.FileInfo {
+	c := instance.Configuration.Response.GetCompression()
+	if !c.IsEnabled() ||
+		len(ctx.Response.Header.Peek("Content-Encoding")) > 0 ||
+		instance.shouldInjectDevScript(fi) ||
+		common.FileSize(fi.Size()) < c.GetMinSize() ||
+		!common.IsCompressibleMimeType(mime.TypeByExtension(sPath.Ext(fi.Name())), c.GetMimeTypes()) {
+		return fi
+	}
+	addVary(ctx, "Accept-Encoding")
+	if len(ctx.Request.Header.Peek("Range")) > 0 {
+		return fi
+	}
+	encoding, ok := common.SelectEncoding(string(ctx.Request.Header.Peek("Accept-Encoding")), c.GetAlgorithms())
+	if !ok {
+		return fi
+	}
+	ctx.Response.Header.Set("Content-Encoding", encoding.String())
+	return &compressedFileInfo{FileInfo: fi, encoding: encoding}
+}
+
+// ServeCompressed responds with the content of the given file compressed on
+// the fly. Files with a checksum which are not bigger than
+// response.compression.maxCacheSize are compressed only once and served from
+// memory afterwards; all others are streamed. The file is closed in every case.
+func (instance *Server) ServeCompressed(f common.File, fi *compressedFileInfo, ctx *fasthttp.RequestCtx) error {
+	maxCacheSize := instance.Configuration.Response.GetCompression().GetMaxCacheSize()
+	key := fi.ChecksumString()
+	if key == "" || common.FileSize(fi.Size()) > maxCacheSize {
+		ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
+			//noinspection GoUnhandledErrorResult
+			defer f.Close()
+			if err := compressTo(f, fi.encoding, w); err != nil {
+				instance.Log().
+					With("event", "compress").
+					With("path", fi.Path()).
+					WithError(err).
+					Warn("Cannot compress file.")
+			}
+		})
+		return nil
+	}
+
+	//noinspection GoUnhandledErrorResult
+	defer f.Close()
+	if b := instance.compressed.get(key); b != nil {
+		ctx.Response.SetBody(b)
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	if err := compressTo(f, fi.encoding, buf); err != nil {
+		return err
+	}
+	instance.compressed.put(key, buf.Bytes(), maxCacheSize)
+	ctx.Response.SetBody(buf.Bytes())
+	return nil
+}
+
+func compressTo(from io.Reader, encoding common.Encoding, to io.Writer) error {
+	w, err := encoding.NewWriter(to, common.CompressionLevelDefault)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, from); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func addVary(ctx *fasthttp.RequestCtx, header string) {
+	for _, existing := range strings.Split(string(ctx.Response.Header.Peek("Vary")), ",") {
+		if strings.EqualFold(strings.TrimSpace(existing), header) {
+			return
+		}
+	}
+	ctx.Response.Header.Add("Vary", header)
+}
+
+// compressedFileInfo describes a file which is compressed on the fly. It has
+// its own checksum (and therefore ETag) for every encoding.
+type compressedFileInfo struct {
+	common.FileInfo
+	encoding common.Encoding
+}
+
+func (instance *compressedFileInfo) ChecksumString() string {
+	if efi, ok := instance.FileInfo.(common.ExtendedFileInfo); ok && efi.ChecksumString() != "" {
+		return efi.ChecksumString() + "-" + instance.encoding.String()
+	}
+	return ""
+}
+
+// compressionCache keeps compressed content in memory. Because it is keyed by
+// checksum entries never need to be invalidated. If the total size exceeds
+// the given maximum the least recently used entries are evicted.
+type compressionCache struct {
+	mutex     sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	totalSize common.FileSize
+}
+
+type compressionCacheEntry struct {
+	key     string
+	content []byte
+}
+
+func (instance *compressionCache) get(key string) []byte {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	if element, ok := instance.entries[key]; ok {
+		instance.lru.MoveToFront(element)
+		return element.Value.(*compressionCacheEntry).content
+	}
+	return nil
+}
+
+func (instance *compressionCache) put(key string, content []byte, maxTotalSize common.FileSize) {
+	size := common.FileSize(len(content))
+	if size > maxTotalSize {
+		return
+	}
+
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	if instance.entries == nil {
+		instance.entries = make(map[string]*list.Element)
+		instance.lru = list.New()
+	}
+	if _, ok := instance.entries[key]; ok {
+		return
+	}
+	for instance.totalSize+size > maxTotalSize {
+		cached := instance.lru.Remove(instance.lru.Back()).(*compressionCacheEntry)
+		delete(instance.entries, cached.key)
+		instance.totalSize -= common.FileSize(len(cached.content))
+	}
+	instance.entries[key] = instance.lru.PushFront(&compressionCacheEntry{key: key, content: content})
+	instance.totalSize += size
+}
+
+func (instance *compressionCache) size() common.FileSize {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	return instance.totalSize
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"github.com/andybalholm/brotli"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Server_compression(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-compression-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	html := strings.Repeat("<p>Hello world!</p>\n", 100)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html"), []byte(html), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "small.html"), []byte("<p>small</p>"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "image.png"), []byte(html), 0644))
+	large := strings.Repeat("<p>Large</p>\n", 200)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "large.html"), []byte(large), 0644))
+	box, err := fs.OpenBox(root)
+	assert.NoError(t, err)
+
+	s := &Server{Box: box}
+	s.Configuration.Response.Compression.Algorithms = []common.Encoding{common.EncodingBrotli, common.EncodingGzip}
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	request := func(path, acceptEncoding string, headers ...string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.Set("Accept-Encoding", acceptEncoding)
+		for i := 0; i+1 < len(headers); i += 2 {
+			ctx.Request.Header.Set(headers[i], headers[i+1])
+		}
+		s.Handle(ctx)
+		return ctx
+	}
+
+	brotliCompressed := request("/index.html", "gzip, br")
+	assert.Equal(t, http.StatusOK, brotliCompressed.Response.StatusCode())
+	assert.Equal(t, "br", string(brotliCompressed.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, "Accept-Encoding", string(brotliCompressed.Response.Header.Peek("Vary")))
+	decoded, err := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(brotliCompressed.Response.Body())))
+	assert.NoError(t, err)
+	assert.Equal(t, html, string(decoded))
+	cached := s.compressed.size()
+	assert.True(t, cached > 0)
+
+	again := request("/index.html", "br")
+	assert.Equal(t, brotliCompressed.Response.Body(), again.Response.Body())
+	assert.Equal(t, cached, s.compressed.size())
+
+	gzipCompressed := request("/index.html", "gzip")
+	assert.Equal(t, "gzip", string(gzipCompressed.Response.Header.Peek("Content-Encoding")))
+	assert.NotEqual(t, string(brotliCompressed.Response.Header.Peek("Etag")), string(gzipCompressed.Response.Header.Peek("Etag")))
+	r, err := gzip.NewReader(bytes.NewReader(gzipCompressed.Response.Body()))
+	assert.NoError(t, err)
+	decoded, err = ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, html, string(decoded))
+
+	notModified := request("/index.html", "gzip", "If-None-Match", string(gzipCompressed.Response.Header.Peek("Etag")))
+	assert.Equal(t, http.StatusNotModified, notModified.Response.StatusCode())
+
+	identity := request("/index.html", "identity")
+	assert.Equal(t, "", string(identity.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, html, string(identity.Response.Body()))
+
+	ranged := request("/index.html", "br", "Range", "bytes=0-9")
+	assert.Equal(t, http.StatusPartialContent, ranged.Response.StatusCode())
+	assert.Equal(t, "", string(ranged.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, html[:10], string(ranged.Response.Body()))
+
+	small := request("/small.html", "br")
+	assert.Equal(t, "", string(small.Response.Header.Peek("Content-Encoding")))
+
+	image := request("/image.png", "br")
+	assert.Equal(t, "", string(image.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, "", string(image.Response.Header.Peek("Vary")))
+
+	maxCacheSize := common.FileSize(len(large) - 1)
+	s.Configuration.Response.Compression.MaxCacheSize = &maxCacheSize
+	cached = s.compressed.size()
+	streamed := request("/large.html", "br")
+	assert.Equal(t, "br", string(streamed.Response.Header.Peek("Content-Encoding")))
+	assert.True(t, streamed.Response.IsBodyStream())
+	decoded, err = ioutil.ReadAll(brotli.NewReader(bytes.NewReader(streamed.Response.Body())))
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(decoded))
+	assert.Equal(t, cached, s.compressed.size())
+	s.Configuration.Response.Compression.MaxCacheSize = nil
+
+	s.Configuration.Response.Compression.Algorithms = nil
+	legacy := true
+	s.Configuration.Response.Gzip = &legacy
+	legacyCompressed := request("/index.html", "gzip, br")
+	assert.Equal(t, "gzip", string(legacyCompressed.Response.Header.Peek("Content-Encoding")))
+}
+
+func Test_compressionCache(t *testing.T) {
+	c := compressionCache{}
+	c.put("a", []byte("aaaa"), 10)
+	c.put("b", []byte("bbbb"), 10)
+	assert.Equal(t, []byte("aaaa"), c.get("a"))
+
+	c.put("c", []byte("cccc"), 10)
+	assert.Nil(t, c.get("b"))
+	assert.Equal(t, []byte("aaaa"), c.get("a"))
+	assert.Equal(t, []byte("cccc"), c.get("c"))
+	assert.Equal(t, common.FileSize(8), c.size())
+
+	c.put("d", []byte("too big to be cached"), 10)
+	assert.Nil(t, c.get("d"))
+}
@@ -0,0 +1,89 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/urfave/cli"
+)
+
+type Cache struct {
+	Enabled      *bool            `yaml:"enabled,omitempty"`
+	MaxEntrySize *common.FileSize `yaml:"maxEntrySize,omitempty"`
+	MaxTotalSize *common.FileSize `yaml:"maxTotalSize,omitempty"`
+}
+
+func (instance Cache) GetEnabled() bool {
+	r := instance.Enabled
+	if r == nil {
+		return false
+	}
+	return *r
+}
+
+func (instance Cache) GetMaxEntrySize() common.FileSize {
+	r := instance.MaxEntrySize
+	if r == nil {
+		return goxr.DefaultCacheMaxEntrySize
+	}
+	return *r
+}
+
+func (instance Cache) GetMaxTotalSize() common.FileSize {
+	r := instance.MaxTotalSize
+	if r == nil {
+		return goxr.DefaultCacheMaxTotalSize
+	}
+	return *r
+}
+
+// Wrap returns the given box wrapped by a goxr.CachedBox if the cache is enabled.
+func (instance Cache) Wrap(box goxr.Box) goxr.Box {
+	if !instance.GetEnabled() {
+		return box
+	}
+	return goxr.NewCachedBox(box, instance.GetMaxEntrySize(), instance.GetMaxTotalSize())
+}
+
+func (instance *Cache) Validate(using goxr.Box) (errors []error) {
+	if instance.GetMaxEntrySize() > instance.GetMaxTotalSize() {
+		errors = append(errors, fmt.Errorf(`cache.maxEntrySize = "%v" - is greater than cache.maxTotalSize = "%v"`, instance.GetMaxEntrySize(), instance.GetMaxTotalSize()))
+	}
+	return
+}
+
+func (instance Cache) Merge(with Cache) Cache {
+	result := instance
+
+	if with.Enabled != nil {
+		result.Enabled = &(*with.Enabled)
+	}
+	if with.MaxEntrySize != nil {
+		result.MaxEntrySize = &(*with.MaxEntrySize)
+	}
+	if with.MaxTotalSize != nil {
+		result.MaxTotalSize = &(*with.MaxTotalSize)
+	}
+
+	return result
+}
+
+func (instance *Cache) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.GenericFlag{
+			Name:  "cache",
+			Usage: "Keeps the content of small files in memory.",
+			Value: &optionalBool{target: &instance.Enabled},
+		},
+		cli.GenericFlag{
+			Name:  "cacheMaxEntrySize",
+			Usage: "Files bigger than this are never cached. Default: " + goxr.DefaultCacheMaxEntrySize.String(),
+			Value: &optionalFileSize{target: &instance.MaxEntrySize},
+		},
+		cli.GenericFlag{
+			Name:  "cacheMaxTotalSize",
+			Usage: "If all cached files exceed this size the least recently used ones are evicted. Default: " + goxr.DefaultCacheMaxTotalSize.String(),
+			Value: &optionalFileSize{target: &instance.MaxTotalSize},
+		},
+	}
+}
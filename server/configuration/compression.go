@@ -0,0 +1,96 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/urfave/cli"
+)
+
+const (
+	DefaultCompressionMinSize      = common.FileSize(1024)
+	DefaultCompressionMaxCacheSize = common.FileSize(32 * 1024 * 1024)
+)
+
+// Compression configures the compression of responses on the fly. It is
+// disabled if no algorithms are configured.
+type Compression struct {
+	// Algorithms are the encodings offered to the clients in order of preference.
+	Algorithms []common.Encoding `yaml:"algorithms,omitempty"`
+	// MinSize is the minimum size of files which are compressed.
+	MinSize *common.FileSize `yaml:"minSize,omitempty"`
+	// MimeTypes of files which are compressed. Entries ending with / are
+	// matching all types with this prefix.
+	MimeTypes []string `yaml:"mimeTypes,omitempty"`
+	// MaxCacheSize is the maximum total size of compressed files which are kept
+	// in memory. Only files with a checksum are cached.
+	MaxCacheSize *common.FileSize `yaml:"maxCacheSize,omitempty"`
+}
+
+func (instance Compression) GetAlgorithms() []common.Encoding {
+	return instance.Algorithms
+}
+
+func (instance Compression) GetMinSize() common.FileSize {
+	r := instance.MinSize
+	if r == nil {
+		return DefaultCompressionMinSize
+	}
+	return *r
+}
+
+func (instance Compression) GetMimeTypes() []string {
+	r := instance.MimeTypes
+	if r == nil {
+		return common.DefaultCompressibleMimeTypes
+	}
+	return r
+}
+
+func (instance Compression) GetMaxCacheSize() common.FileSize {
+	r := instance.MaxCacheSize
+	if r == nil {
+		return DefaultCompressionMaxCacheSize
+	}
+	return *r
+}
+
+// IsEnabled returns true if at least one algorithm is configured.
+func (instance Compression) IsEnabled() bool {
+	return len(instance.GetAlgorithms()) > 0
+}
+
+func (instance *Compression) Validate(using goxr.Box) (errors []error) {
+	for i, algorithm := range instance.Algorithms {
+		if _, err := common.ParseEncoding(string(algorithm)); err != nil {
+			errors = append(errors, fmt.Errorf(`response.compression.algorithms[%d] = "%v" - %v`, i, algorithm, err))
+		}
+	}
+	if instance.GetMinSize() < 0 {
+		errors = append(errors, fmt.Errorf(`response.compression.minSize = "%v" - must not be negative`, instance.GetMinSize()))
+	}
+	return
+}
+
+func (instance Compression) Merge(with Compression) Compression {
+	result := instance
+
+	if with.Algorithms != nil {
+		result.Algorithms = append([]common.Encoding{}, with.Algorithms...)
+	}
+	if with.MinSize != nil {
+		result.MinSize = &(*with.MinSize)
+	}
+	if with.MimeTypes != nil {
+		result.MimeTypes = append([]string{}, with.MimeTypes...)
+	}
+	if with.MaxCacheSize != nil {
+		result.MaxCacheSize = &(*with.MaxCacheSize)
+	}
+
+	return result
+}
+
+func (instance *Compression) Flags() []cli.Flag {
+	return []cli.Flag{}
+}
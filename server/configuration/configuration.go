@@ -44,6 +44,7 @@ type Configuration struct {
 	Paths    Paths    `yaml:"paths,omitempty"`
 	Response Response `yaml:"response,omitempty"`
 	Logging  Logging  `yaml:"logging,omitempty"`
+	Cache    Cache    `yaml:"cache,omitempty"`
 }
 
 func (instance *Configuration) Flags() (result []cli.Flag) {
@@ -51,6 +52,7 @@ func (instance *Configuration) Flags() (result []cli.Flag) {
 	result = append(result, instance.Paths.Flags()...)
 	result = append(result, instance.Response.Flags()...)
 	result = append(result, instance.Logging.Flags()...)
+	result = append(result, instance.Cache.Flags()...)
 	return result
 }
 
@@ -60,14 +62,17 @@ func (instance Configuration) Merge(with Configuration) (result Configuration) {
 	result.Paths = result.Paths.Merge(with.Paths)
 	result.Response = result.Response.Merge(with.Response)
 	result.Logging = result.Logging.Merge(with.Logging)
+	result.Cache = result.Cache.Merge(with.Cache)
 	return result
 }
 
 func (instance *Configuration) Validate(using goxr.Box) (errors []error) {
+	instance.Paths.deny(instance.Listen.Tls.BoxPaths())
 	errors = append(errors, instance.Listen.Validate(using)...)
 	errors = append(errors, instance.Paths.Validate(using)...)
 	errors = append(errors, instance.Response.Validate(using)...)
 	errors = append(errors, instance.Logging.Validate(using)...)
+	errors = append(errors, instance.Cache.Validate(using)...)
 	return
 }
 
@@ -0,0 +1,132 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/entry"
+	"github.com/urfave/cli"
+	"os"
+)
+
+type Fingerprints struct {
+	Manifest       *string `yaml:"manifest,omitempty"`
+	ResolveLogical *bool   `yaml:"resolveLogical,omitempty"`
+	Immutable      *bool   `yaml:"immutable,omitempty"`
+
+	logicalToFingerprinted map[string]string
+	fingerprinted          map[string]bool
+}
+
+func (instance Fingerprints) GetManifest() string {
+	r := instance.Manifest
+	if r == nil {
+		return "/" + packed.AssetManifestFilename
+	}
+	return *r
+}
+
+func (instance Fingerprints) GetResolveLogical() bool {
+	r := instance.ResolveLogical
+	if r == nil {
+		return false
+	}
+	return *r
+}
+
+func (instance Fingerprints) GetImmutable() bool {
+	r := instance.Immutable
+	if r == nil {
+		return true
+	}
+	return *r
+}
+
+func (instance Fingerprints) Resolve(candidate string) string {
+	if !instance.GetResolveLogical() {
+		return candidate
+	}
+	if fingerprinted, ok := instance.logicalToFingerprinted[entry.CleanPath(candidate)]; ok {
+		return "/" + fingerprinted
+	}
+	return candidate
+}
+
+func (instance Fingerprints) IsFingerprinted(candidate string) bool {
+	return instance.fingerprinted[entry.CleanPath(candidate)]
+}
+
+func (instance *Fingerprints) Validate(using goxr.Box) (errors []error) {
+	instance.logicalToFingerprinted = nil
+	instance.fingerprinted = nil
+
+	r := instance.GetManifest()
+	if r == "" {
+		return
+	}
+	if f, err := using.Open(r); os.IsNotExist(err) {
+		if instance.Manifest != nil {
+			errors = append(errors, fmt.Errorf(`paths.fingerprints.manifest = "%s" - path does not exist in box`, r))
+		}
+	} else if err != nil {
+		errors = append(errors, fmt.Errorf(`paths.fingerprints.manifest = "%s" - cannot open: %v`, r, err))
+	} else {
+		//noinspection GoUnhandledErrorResult
+		defer f.Close()
+		var m map[string]string
+		if err := json.NewDecoder(f).Decode(&m); err != nil {
+			// A file at the default location which is not a fingerprints
+			// manifest simply leaves fingerprinting disabled.
+			if instance.Manifest != nil {
+				errors = append(errors, fmt.Errorf(`paths.fingerprints.manifest = "%s" - cannot parse: %v`, r, err))
+			}
+		} else {
+			instance.logicalToFingerprinted = make(map[string]string, len(m))
+			instance.fingerprinted = make(map[string]bool, len(m))
+			for logical, fingerprinted := range m {
+				instance.logicalToFingerprinted[entry.CleanPath(logical)] = entry.CleanPath(fingerprinted)
+				instance.fingerprinted[entry.CleanPath(fingerprinted)] = true
+			}
+		}
+	}
+	return
+}
+
+func (instance Fingerprints) Merge(with Fingerprints) Fingerprints {
+	result := instance
+
+	if with.Manifest != nil {
+		result.Manifest = &(*with.Manifest)
+		result.logicalToFingerprinted = nil
+		result.fingerprinted = nil
+	}
+	if with.ResolveLogical != nil {
+		result.ResolveLogical = &(*with.ResolveLogical)
+	}
+	if with.Immutable != nil {
+		result.Immutable = &(*with.Immutable)
+	}
+
+	return result
+}
+
+func (instance *Fingerprints) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.GenericFlag{
+			Name:  "fingerprintsManifest",
+			Usage: "Path of the asset manifest inside the box which maps logical to fingerprinted paths. Default: /" + packed.AssetManifestFilename,
+			Value: &optionalString{target: &instance.Manifest},
+		},
+		cli.GenericFlag{
+			Name:  "resolveFingerprints",
+			Usage: "Serves fingerprinted assets also at their logical paths.",
+			Value: &optionalBool{target: &instance.ResolveLogical},
+		},
+		cli.GenericFlag{
+			Name:  "fingerprintsImmutable",
+			Usage: "Serves fingerprinted assets with 'Cache-Control: public, max-age=31536000, immutable'. Default: true",
+			Value: &optionalBool{target: &instance.Immutable},
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package configuration
+
+import (
+	"github.com/echocat/goxr/common"
+	"strconv"
+)
+
+// optionalBool binds a boolean flag to an optional field which remains nil if
+// the flag is absent.
+type optionalBool struct {
+	target **bool
+}
+
+func (instance *optionalBool) Set(plain string) error {
+	v, err := strconv.ParseBool(plain)
+	if err != nil {
+		return err
+	}
+	*instance.target = &v
+	return nil
+}
+
+func (instance *optionalBool) String() string {
+	if instance.target == nil || *instance.target == nil {
+		return ""
+	}
+	return strconv.FormatBool(**instance.target)
+}
+
+func (instance *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// optionalString binds a flag to an optional field which remains nil if the
+// flag is absent.
+type optionalString struct {
+	target **string
+}
+
+func (instance *optionalString) Set(plain string) error {
+	*instance.target = &plain
+	return nil
+}
+
+func (instance *optionalString) String() string {
+	if instance.target == nil || *instance.target == nil {
+		return ""
+	}
+	return **instance.target
+}
+
+// optionalFileSize binds a flag to an optional field which remains nil if the
+// flag is absent.
+type optionalFileSize struct {
+	target **common.FileSize
+}
+
+func (instance *optionalFileSize) Set(plain string) error {
+	var v common.FileSize
+	if err := v.Set(plain); err != nil {
+		return err
+	}
+	*instance.target = &v
+	return nil
+}
+
+func (instance *optionalFileSize) String() string {
+	if instance.target == nil || *instance.target == nil {
+		return ""
+	}
+	return (**instance.target).String()
+}
@@ -1,19 +1,47 @@
 package configuration
 
 import (
+	"fmt"
 	"github.com/echocat/goxr"
 	"github.com/urfave/cli"
+	"strings"
 )
 
 type Listen struct {
-	HttpAddress HttpAddress `yaml:"httpAddress,omitempty"`
+	HttpAddress  HttpAddress  `yaml:"httpAddress,omitempty"`
+	HttpsAddress HttpsAddress `yaml:"httpsAddress,omitempty"`
+	HttpMode     HttpMode     `yaml:"httpMode,omitempty"`
+	AdminAddress AdminAddress `yaml:"adminAddress,omitempty"`
+	Tls          Tls          `yaml:"tls,omitempty"`
 }
 
 func (instance Listen) GetHttpAddress() string {
 	return instance.HttpAddress.String()
 }
 
+func (instance Listen) GetHttpsAddress() string {
+	return instance.HttpsAddress.String()
+}
+
+func (instance Listen) GetHttpMode() HttpMode {
+	if instance.HttpMode == "" {
+		return HttpModeServe
+	}
+	return instance.HttpMode
+}
+
+func (instance Listen) GetAdminAddress() string {
+	return instance.AdminAddress.String()
+}
+
 func (instance *Listen) Validate(using goxr.Box) (errors []error) {
+	if instance.GetHttpsAddress() != "" && len(instance.Tls.GetCertificates()) == 0 && !instance.Tls.GetSelfSigned() {
+		errors = append(errors, fmt.Errorf(`listen.httpsAddress = "%s" - requires either at least one of listen.tls.certificates or listen.tls.selfSigned`, instance.GetHttpsAddress()))
+	}
+	if instance.GetHttpMode() == HttpModeRedirect && instance.GetHttpsAddress() == "" {
+		errors = append(errors, fmt.Errorf(`listen.httpMode = "%v" - requires listen.httpsAddress`, instance.GetHttpMode()))
+	}
+	errors = append(errors, instance.Tls.Validate(using)...)
 	return
 }
 
@@ -31,20 +59,104 @@ func (instance HttpAddress) String() string {
 	return string(instance)
 }
 
+// HttpsAddress is the address where HTTPS requests are accepted. It is
+// disabled by default.
+type HttpsAddress string
+
+func (instance *HttpsAddress) Set(plain string) error {
+	*instance = HttpsAddress(plain)
+	return nil
+}
+
+func (instance HttpsAddress) String() string {
+	return string(instance)
+}
+
+// HttpMode defines how requests at listen.httpAddress are handled.
+type HttpMode string
+
+const (
+	// HttpModeServe serves the content of the box.
+	HttpModeServe = HttpMode("serve")
+	// HttpModeRedirect redirects every request to listen.httpsAddress.
+	HttpModeRedirect = HttpMode("redirect")
+)
+
+func (instance *HttpMode) Set(plain string) error {
+	switch v := HttpMode(strings.ToLower(plain)); v {
+	case "", HttpModeServe, HttpModeRedirect:
+		*instance = v
+		return nil
+	}
+	return fmt.Errorf("illegal http mode '%s' - supported are: %v", plain, []HttpMode{HttpModeServe, HttpModeRedirect})
+}
+
+func (instance *HttpMode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain string
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	return instance.Set(plain)
+}
+
+func (instance HttpMode) String() string {
+	return string(instance)
+}
+
+// AdminAddress is the address where admin requests are accepted. It is
+// disabled by default.
+type AdminAddress string
+
+func (instance *AdminAddress) Set(plain string) error {
+	*instance = AdminAddress(plain)
+	return nil
+}
+
+func (instance AdminAddress) String() string {
+	return string(instance)
+}
+
 func (instance Listen) Merge(with Listen) Listen {
 	result := instance
 	if with.HttpAddress != "" {
 		result.HttpAddress = with.HttpAddress
 	}
+	if with.HttpsAddress != "" {
+		result.HttpsAddress = with.HttpsAddress
+	}
+	if with.HttpMode != "" {
+		result.HttpMode = with.HttpMode
+	}
+	if with.AdminAddress != "" {
+		result.AdminAddress = with.AdminAddress
+	}
+	result.Tls = result.Tls.Merge(with.Tls)
 	return result
 }
 
 func (instance *Listen) Flags() []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		cli.GenericFlag{
 			Name:  "httpAddress",
 			Usage: "Address where to listen to.",
 			Value: &instance.HttpAddress,
 		},
-	}
+		cli.GenericFlag{
+			Name:  "httpsAddress",
+			Usage: "Address where to listen to for HTTPS requests. If empty HTTPS is disabled. Requires --tlsCertificate and --tlsKey or --tlsSelfSigned.",
+			Value: &instance.HttpsAddress,
+		},
+		cli.GenericFlag{
+			Name: "httpMode",
+			Usage: "How requests at --httpAddress are handled: serve (serves the content) or redirect (redirects" +
+				"\n     every request to --httpsAddress).",
+			Value: &instance.HttpMode,
+		},
+		cli.GenericFlag{
+			Name: "adminAddress",
+			Usage: "Address where to listen to for admin requests like 'POST /box/reload'. If empty no admin" +
+				"\n     requests are accepted. This address should not be accessible by the public.",
+			Value: &instance.AdminAddress,
+		},
+	}, instance.Tls.Flags()...)
 }
@@ -3,19 +3,26 @@ package configuration
 import (
 	"fmt"
 	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/entry"
 	"github.com/urfave/cli"
+	"net/http"
 	"os"
 	"regexp"
 )
 
 type Paths struct {
-	Catchall    Catchall       `yaml:"catchall,omitempty"`
-	Index       *string        `yaml:"index,omitempty"`
-	StatusCodes map[int]string `yaml:"statusCodes,omitempty"`
-	Includes    *[]string      `yaml:"includes,omitempty"`
-	Excludes    *[]string      `yaml:"excludes,omitempty"`
+	Catchall     Catchall       `yaml:"catchall,omitempty"`
+	Index        *string        `yaml:"index,omitempty"`
+	StatusCodes  map[int]string `yaml:"statusCodes,omitempty"`
+	Includes     *[]string      `yaml:"includes,omitempty"`
+	Excludes     *[]string      `yaml:"excludes,omitempty"`
+	Fingerprints Fingerprints   `yaml:"fingerprints,omitempty"`
+	// DeniedStatusCode is the status code of responses for paths which are
+	// not allowed by Includes and Excludes. Either 404 (default) or 403.
+	DeniedStatusCode *int `yaml:"deniedStatusCode,omitempty"`
 
 	defaultFallback     string
+	denied              []string
 	includesRegexpCache *[]*regexp.Regexp
 	excludesRegexpCache *[]*regexp.Regexp
 }
@@ -80,6 +87,14 @@ func (instance Paths) cloneExcludes() []string {
 	return r
 }
 
+func (instance Paths) GetDeniedStatusCode() int {
+	r := instance.DeniedStatusCode
+	if r == nil {
+		return http.StatusNotFound
+	}
+	return *r
+}
+
 func (instance *Paths) FindStatusCode(code int) string {
 	r := instance.StatusCodes
 	if r == nil {
@@ -88,7 +103,25 @@ func (instance *Paths) FindStatusCode(code int) string {
 	return r[code]
 }
 
+// deny refuses the given paths independent of Includes and Excludes.
+func (instance *Paths) deny(paths []string) {
+	instance.denied = make([]string, len(paths))
+	for i, path := range paths {
+		instance.denied[i] = "/" + entry.CleanPath(path)
+	}
+}
+
+// PathAllowed checks the given path inside of the box against Includes and
+// Excludes. The path is always matched with a leading slash (/foo/bar.html).
+// Certificates and keys located inside of the box (see Tls.BoxPaths) are never
+// allowed.
 func (instance *Paths) PathAllowed(candidate string) (bool, error) {
+	candidate = "/" + entry.CleanPath(candidate)
+	for _, denied := range instance.denied {
+		if candidate == denied {
+			return false, nil
+		}
+	}
 	includes := instance.includesRegexpCache
 	excludes := instance.excludesRegexpCache
 	for i := 0; i < 100 && includes == nil; i++ {
@@ -133,10 +166,44 @@ func (instance *Paths) PathAllowed(candidate string) (bool, error) {
 
 func (instance *Paths) Validate(using goxr.Box) (errors []error) {
 	errors = append(errors, instance.Catchall.Validate(using)...)
+	errors = append(errors, instance.Fingerprints.Validate(using)...)
 	errors = append(errors, instance.validateIndex(using)...)
 	errors = append(errors, instance.validateStatusCodes(using)...)
 	errors = append(errors, instance.rebuildIncludesCache()...)
 	errors = append(errors, instance.rebuildExcludesCache()...)
+	errors = append(errors, instance.validateDeniedStatusCode()...)
+	if len(errors) == 0 {
+		errors = append(errors, instance.validateTargetsAllowed()...)
+	}
+	return
+}
+
+func (instance *Paths) validateDeniedStatusCode() (errors []error) {
+	if code := instance.GetDeniedStatusCode(); code != http.StatusNotFound && code != http.StatusForbidden {
+		errors = append(errors, fmt.Errorf(`paths.deniedStatusCode = %d - only %d and %d are supported`, code, http.StatusNotFound, http.StatusForbidden))
+	}
+	return
+}
+
+// validateTargetsAllowed ensures that the explicitly configured targets are not
+// denied by Includes and Excludes.
+func (instance *Paths) validateTargetsAllowed() (errors []error) {
+	check := func(name string, path string) {
+		if path == "" {
+			return
+		} else if allowed, err := instance.PathAllowed(path); err != nil {
+			errors = append(errors, err)
+		} else if !allowed {
+			errors = append(errors, fmt.Errorf(`%s = "%s" - path is not allowed by paths.includes and paths.excludes`, name, path))
+		}
+	}
+	if r := instance.Index; r != nil {
+		check("paths.index", *r)
+	}
+	for code, path := range instance.GetStatusCodes() {
+		check(fmt.Sprintf("paths.statusCodes[%d]", code), path)
+	}
+	check("paths.catchall.target", instance.Catchall.GetTarget())
 	return
 }
 
@@ -204,6 +271,7 @@ func (instance Paths) Merge(with Paths) Paths {
 	result := instance
 
 	result.Catchall = result.Catchall.Merge(with.Catchall)
+	result.Fingerprints = result.Fingerprints.Merge(with.Fingerprints)
 
 	if with.Index != nil {
 		result.Index = &(*with.Index)
@@ -222,10 +290,15 @@ func (instance Paths) Merge(with Paths) Paths {
 		result.Excludes = &v
 		result.excludesRegexpCache = nil
 	}
+	if with.DeniedStatusCode != nil {
+		result.DeniedStatusCode = &(*with.DeniedStatusCode)
+	}
 
 	return result
 }
 
-func (instance *Paths) Flags() []cli.Flag {
-	return []cli.Flag{}
+func (instance *Paths) Flags() (result []cli.Flag) {
+	result = append(result, instance.Catchall.Flags()...)
+	result = append(result, instance.Fingerprints.Flags()...)
+	return
 }
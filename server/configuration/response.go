@@ -2,12 +2,15 @@ package configuration
 
 import (
 	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
 	"github.com/urfave/cli"
 )
 
 type Response struct {
 	MimeTypes        map[string]string   `yaml:"mimeTypes,omitempty"`
+	Compression      Compression         `yaml:"compression,omitempty"`
 	Gzip             *bool               `yaml:"gzip,omitempty"`
+	Precompressed    *bool               `yaml:"precompressed,omitempty"`
 	Headers          map[string][]string `yaml:"headers,omitempty"`
 	WithEtag         *bool               `yaml:"withEtag,omitempty"`
 	WithLastModified *bool               `yaml:"withLastModified,omitempty"`
@@ -30,6 +33,8 @@ func (instance Response) cloneMimeTypes() map[string]string {
 	return r
 }
 
+// Deprecated: GetGzip is replaced by GetCompression; gzip: true is the same
+// as compression.algorithms: [gzip].
 func (instance Response) GetGzip() bool {
 	r := instance.Gzip
 	if r == nil {
@@ -38,6 +43,26 @@ func (instance Response) GetGzip() bool {
 	return *r
 }
 
+// GetCompression returns the compression configuration. If no algorithms are
+// configured but the legacy gzip option is enabled gzip is used.
+func (instance Response) GetCompression() Compression {
+	r := instance.Compression
+	if r.Algorithms == nil && instance.GetGzip() {
+		r.Algorithms = []common.Encoding{common.EncodingGzip}
+	}
+	return r
+}
+
+// GetPrecompressed returns true if precompressed siblings of entries (for
+// example index.html.br) should be served to clients accepting the encoding.
+func (instance Response) GetPrecompressed() bool {
+	r := instance.Precompressed
+	if r == nil {
+		return true
+	}
+	return *r
+}
+
 func (instance Response) GetHeaders() map[string][]string {
 	r := instance.Headers
 	if r == nil {
@@ -82,6 +107,7 @@ func (instance Response) GetWithContentType() bool {
 }
 
 func (instance *Response) Validate(using goxr.Box) (errors []error) {
+	errors = append(errors, instance.Compression.Validate(using)...)
 	return
 }
 
@@ -91,9 +117,13 @@ func (instance Response) Merge(with Response) Response {
 	if with.MimeTypes != nil {
 		result.MimeTypes = with.cloneMimeTypes()
 	}
+	result.Compression = result.Compression.Merge(with.Compression)
 	if with.Gzip != nil {
 		result.Gzip = &(*with.Gzip)
 	}
+	if with.Precompressed != nil {
+		result.Precompressed = &(*with.Precompressed)
+	}
 	if with.Headers != nil {
 		result.Headers = with.cloneHeaders()
 	}
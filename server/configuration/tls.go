@@ -0,0 +1,172 @@
+package configuration
+
+import (
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/urfave/cli"
+	"strings"
+	"time"
+)
+
+// Tls configures the certificates served at listen.httpsAddress.
+type Tls struct {
+	// CertificateFile and KeyFile are the PEM encoded certificate (chain) and
+	// private key of the primary certificate.
+	CertificateFile string `yaml:"certificateFile,omitempty"`
+	KeyFile         string `yaml:"keyFile,omitempty"`
+	// Certificates are additional certificates. For every connection the
+	// certificate matching the requested server name (SNI) is selected.
+	Certificates []Certificate `yaml:"certificates,omitempty"`
+	// SelfSigned generates a self-signed certificate for localhost if no
+	// certificate is configured. Only useful for development.
+	SelfSigned *bool `yaml:"selfSigned,omitempty"`
+	// ReloadInterval is the interval certificate files are polled with for
+	// changes. If zero they are only reloaded on SIGHUP or 'POST /tls/reload'
+	// at listen.adminAddress.
+	ReloadInterval *time.Duration `yaml:"reloadInterval,omitempty"`
+}
+
+type Certificate struct {
+	Certificate string              `yaml:"certificate"`
+	Key         string              `yaml:"key"`
+	Location    CertificateLocation `yaml:"location,omitempty"`
+}
+
+// GetCertificates returns the primary certificate (if configured) followed by
+// the additional ones.
+func (instance Tls) GetCertificates() []Certificate {
+	var result []Certificate
+	if instance.CertificateFile != "" || instance.KeyFile != "" {
+		result = append(result, Certificate{
+			Certificate: instance.CertificateFile,
+			Key:         instance.KeyFile,
+			Location:    CertificateLocationFile,
+		})
+	}
+	return append(result, instance.Certificates...)
+}
+
+// BoxPaths returns the paths of all certificates and keys which are located
+// inside of the box. These must never be served.
+func (instance Tls) BoxPaths() (result []string) {
+	for _, certificate := range instance.GetCertificates() {
+		if certificate.Location.IsBox() {
+			result = append(result, certificate.Certificate, certificate.Key)
+		}
+	}
+	return
+}
+
+func (instance Tls) GetSelfSigned() bool {
+	r := instance.SelfSigned
+	if r == nil {
+		return false
+	}
+	return *r
+}
+
+func (instance Tls) GetReloadInterval() time.Duration {
+	r := instance.ReloadInterval
+	if r == nil {
+		return 0
+	}
+	return *r
+}
+
+func (instance *Tls) Validate(using goxr.Box) (errors []error) {
+	for i, certificate := range instance.GetCertificates() {
+		if certificate.Certificate == "" || certificate.Key == "" {
+			errors = append(errors, fmt.Errorf(`listen.tls.certificates[%d] - certificate and key are required`, i))
+		} else if certificate.Location.IsBox() && using != nil {
+			for _, name := range []string{certificate.Certificate, certificate.Key} {
+				if _, err := using.Info(name); err != nil {
+					errors = append(errors, fmt.Errorf(`listen.tls.certificates[%d] = "%s" - %v`, i, name, err))
+				}
+			}
+		}
+	}
+	if instance.GetReloadInterval() < 0 {
+		errors = append(errors, fmt.Errorf(`listen.tls.reloadInterval = "%v" - must not be negative`, instance.GetReloadInterval()))
+	}
+	return
+}
+
+func (instance Tls) Merge(with Tls) Tls {
+	result := instance
+
+	if with.CertificateFile != "" {
+		result.CertificateFile = with.CertificateFile
+	}
+	if with.KeyFile != "" {
+		result.KeyFile = with.KeyFile
+	}
+	if with.Certificates != nil {
+		result.Certificates = append([]Certificate{}, with.Certificates...)
+	}
+	if with.SelfSigned != nil {
+		result.SelfSigned = &(*with.SelfSigned)
+	}
+	if with.ReloadInterval != nil {
+		result.ReloadInterval = &(*with.ReloadInterval)
+	}
+
+	return result
+}
+
+func (instance *Tls) Flags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:        "tlsCertificate",
+			Usage:       "PEM file which contains the certificate (chain) served at --httpsAddress.",
+			Destination: &instance.CertificateFile,
+		},
+		cli.StringFlag{
+			Name:        "tlsKey",
+			Usage:       "PEM file which contains the private key of --tlsCertificate.",
+			Destination: &instance.KeyFile,
+		},
+		cli.GenericFlag{
+			Name: "tlsSelfSigned",
+			Usage: "Serves a generated self-signed certificate for localhost at --httpsAddress if no certificate is" +
+				"\n     configured. Only useful for development.",
+			Value: &optionalBool{target: &instance.SelfSigned},
+		},
+	}
+}
+
+// CertificateLocation defines where certificate and key are read from.
+type CertificateLocation string
+
+const (
+	CertificateLocationFile = CertificateLocation("file")
+	CertificateLocationBox  = CertificateLocation("box")
+)
+
+func (instance *CertificateLocation) Set(plain string) error {
+	switch v := CertificateLocation(strings.ToLower(plain)); v {
+	case "", CertificateLocationFile, CertificateLocationBox:
+		*instance = v
+		return nil
+	}
+	return fmt.Errorf("illegal certificate location '%s' - supported are: %v", plain, []CertificateLocation{CertificateLocationFile, CertificateLocationBox})
+}
+
+func (instance *CertificateLocation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain string
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	return instance.Set(plain)
+}
+
+func (instance CertificateLocation) String() string {
+	if instance == "" {
+		return string(CertificateLocationFile)
+	}
+	return string(instance)
+}
+
+// IsBox returns true if certificate and key are PEM files inside of the box.
+func (instance CertificateLocation) IsBox() bool {
+	return instance == CertificateLocationBox
+}
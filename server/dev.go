@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/valyala/fasthttp"
+	"io"
+	"io/ioutil"
+	"mime"
+	sPath "path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DevEventsPath = "/.goxr/dev/events"
+	DevScriptPath = "/.goxr/dev/reload.js"
+
+	devKeepAliveInterval = 15 * time.Second
+	devReloadScript      = `(function () {
+  var source = new EventSource("` + DevEventsPath + `");
+  source.addEventListener("change", function () {
+    source.close();
+    window.location.reload();
+  });
+})();
+`
+)
+
+var devScriptTag = []byte(`<script src="` + DevScriptPath + `"></script>`)
+
+type devReloader struct {
+	mutex     sync.Mutex
+	listeners map[chan common.ChangeEvent]struct{}
+	watcher   io.Closer
+}
+
+func (instance *Server) configureDev() error {
+	if !instance.Dev || instance.dev != nil {
+		return nil
+	}
+	reloader := &devReloader{
+		listeners: make(map[chan common.ChangeEvent]struct{}),
+	}
+	if err := instance.watchDev(reloader, instance.Box); err != nil {
+		return err
+	}
+	instance.dev = reloader
+	return nil
+}
+
+// watchDev lets the given reloader watch the given box. A watcher of a
+// previous box is closed afterwards.
+func (instance *Server) watchDev(reloader *devReloader, box goxr.Box) error {
+	wb, ok := box.(goxr.Watchable)
+	if !ok {
+		return fmt.Errorf("dev mode requires a box which supports watching, but got: %T", box)
+	}
+	watcher, err := wb.Watch(func(event common.ChangeEvent) {
+		instance.Log().
+			With("event", "devChange").
+			With("path", event.Path).
+			With("type", event.Type).
+			Debug()
+		reloader.broadcast(event)
+	})
+	if err != nil {
+		return err
+	}
+
+	reloader.mutex.Lock()
+	old := reloader.watcher
+	reloader.watcher = watcher
+	reloader.mutex.Unlock()
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (instance *devReloader) subscribe() chan common.ChangeEvent {
+	result := make(chan common.ChangeEvent, 10)
+	instance.mutex.Lock()
+	instance.listeners[result] = struct{}{}
+	instance.mutex.Unlock()
+	return result
+}
+
+func (instance *devReloader) unsubscribe(listener chan common.ChangeEvent) {
+	instance.mutex.Lock()
+	delete(instance.listeners, listener)
+	instance.mutex.Unlock()
+}
+
+func (instance *devReloader) broadcast(event common.ChangeEvent) {
+	instance.mutex.Lock()
+	defer instance.mutex.Unlock()
+	for listener := range instance.listeners {
+		select {
+		case listener <- event:
+		default:
+		}
+	}
+}
+
+// Close stops watching the box.
+func (instance *devReloader) Close() error {
+	instance.mutex.Lock()
+	watcher := instance.watcher
+	instance.watcher = nil
+	instance.mutex.Unlock()
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
+
+// HandleDev serves the endpoints of the dev mode. It returns true if the
+// request was handled.
+func (instance *Server) HandleDev(ctx *fasthttp.RequestCtx) bool {
+	reloader := instance.dev
+	if reloader == nil {
+		return false
+	}
+	switch string(ctx.Path()) {
+	case DevScriptPath:
+		ctx.Response.Header.Set("Cache-Control", "no-cache")
+		ctx.SetContentType("application/javascript")
+		ctx.SetBodyString(devReloadScript)
+		return true
+	case DevEventsPath:
+		events := reloader.subscribe()
+		ctx.Response.Header.Set("Cache-Control", "no-cache")
+		ctx.SetContentType("text/event-stream")
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer reloader.unsubscribe(events)
+			ticker := time.NewTicker(devKeepAliveInterval)
+			defer ticker.Stop()
+
+			_, _ = fmt.Fprint(w, ": connected\n\n")
+			for w.Flush() == nil {
+				select {
+				case event := <-events:
+					_, _ = fmt.Fprintf(w, "event: change\ndata: %s\n\n", event.Path)
+				case <-ticker.C:
+					_, _ = fmt.Fprint(w, ": keepalive\n\n")
+				}
+			}
+		})
+		return true
+	}
+	return false
+}
+
+func (instance *Server) shouldInjectDevScript(fi common.FileInfo) bool {
+	return instance.dev != nil && strings.HasPrefix(mime.TypeByExtension(sPath.Ext(fi.Name())), "text/html")
+}
+
+func (instance *Server) injectDevScript(f common.File) ([]byte, error) {
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if i := bytes.LastIndex(bytes.ToLower(b), []byte("</body>")); i >= 0 {
+		result := make([]byte, 0, len(b)+len(devScriptTag))
+		result = append(result, b[:i]...)
+		result = append(result, devScriptTag...)
+		return append(result, b[i:]...), nil
+	}
+	return append(b, devScriptTag...), nil
+}
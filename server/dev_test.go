@@ -0,0 +1,98 @@
+package server
+
+import (
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func Test_Server_dev(t *testing.T) {
+	box, err := fs.OpenBox("../resources/testBase1")
+	assert.NoError(t, err)
+	s := Server{
+		Box: box,
+		Dev: true,
+	}
+	assert.NoError(t, s.configureDev())
+	defer func() {
+		assert.NoError(t, s.dev.Close())
+		assert.NoError(t, s.dev.Close(), "closing twice must be harmless")
+	}()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(DevScriptPath)
+	assert.True(t, s.HandleDev(ctx))
+	assert.Contains(t, string(ctx.Response.Body()), DevEventsPath)
+
+	ctx = &fasthttp.RequestCtx{}
+	s.ServeFile(box, "index.html", ctx, false, http.StatusOK)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	assert.Contains(t, string(ctx.Response.Body()), string(devScriptTag))
+	assert.Empty(t, ctx.Response.Header.Peek("Etag"))
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/index.html")
+	assert.False(t, s.HandleDev(ctx))
+}
+
+func Test_Server_dev_disabled(t *testing.T) {
+	box, err := fs.OpenBox("../resources/testBase1")
+	assert.NoError(t, err)
+	s := Server{
+		Box: box,
+	}
+	assert.NoError(t, s.configureDev())
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(DevScriptPath)
+	assert.False(t, s.HandleDev(ctx))
+
+	ctx = &fasthttp.RequestCtx{}
+	s.ServeFile(box, "index.html", ctx, false, http.StatusOK)
+	assert.NotContains(t, string(ctx.Response.Body()), string(devScriptTag))
+	assert.NotEmpty(t, ctx.Response.Header.Peek("Etag"))
+}
+
+func Test_Server_dev_SwapBox(t *testing.T) {
+	old := &watchRecordingBox{Box: openTestBase1ForT(t)}
+	s := Server{Box: old, Dev: true}
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.dev.Close())
+	}()
+	assert.True(t, old.watching)
+
+	replacement := &watchRecordingBox{Box: openTestBase1ForT(t)}
+	assert.NoError(t, s.SwapBox(replacement))
+	assert.False(t, old.watching)
+	assert.True(t, replacement.watching)
+
+	events := s.dev.subscribe()
+	defer s.dev.unsubscribe(events)
+	replacement.listener(common.ChangeEvent{Path: "index.html"})
+	assert.Equal(t, "index.html", (<-events).Path)
+
+	assert.Error(t, s.SwapBox(&closeRecordingBox{Box: openTestBase1ForT(t)}), "dev mode requires a watchable box")
+	assert.Equal(t, replacement, s.Box.(*leasedBox).box)
+	assert.True(t, replacement.watching)
+}
+
+type watchRecordingBox struct {
+	goxr.Box
+	listener common.ChangeListener
+	watching bool
+}
+
+func (instance *watchRecordingBox) Watch(listener common.ChangeListener) (io.Closer, error) {
+	instance.listener = listener
+	instance.watching = true
+	return common.NewOnceCloser(func() error {
+		instance.watching = false
+		return nil
+	}), nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Server_fingerprints(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-fingerprints-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, ".goxr"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "manifest.json"), []byte(`{"name":"app","icons":[{"src":"icon.png"}]}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, ".goxr", "fingerprints.json"), []byte(`{"app.js":"app.3f2a9c.js"}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "app.3f2a9c.js"), []byte("alert(1)"), 0644))
+	box, err := fs.OpenBox(root)
+	assert.NoError(t, err)
+
+	s := &Server{Box: box}
+	resolve := true
+	s.Configuration.Paths.Fingerprints.ResolveLogical = &resolve
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/app.js")
+	s.Handle(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "alert(1)", string(ctx.Response.Body()))
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/manifest.json")
+	s.Handle(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+
+	t.Run("defaultLocationNotParsable", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(root, ".goxr", "fingerprints.json"), []byte(`[]`), 0644))
+		c := s.Configuration
+		c.Paths.Fingerprints.Manifest = nil
+		assert.Empty(t, c.Validate(box))
+		assert.False(t, c.Paths.Fingerprints.IsFingerprinted("app.3f2a9c.js"))
+
+		manifest := "/manifest.json"
+		c.Paths.Fingerprints.Manifest = &manifest
+		assert.NotEmpty(t, c.Validate(box))
+	})
+}
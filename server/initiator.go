@@ -21,6 +21,16 @@ type Initiator struct {
 
 	Phases []InitiatorPhase
 	Fail   func(initiator *Initiator, err error)
+
+	// AllowSymlinksOutsideOfBase disables the strict mode of base directories
+	// which refuses files which symlinks are pointing outside of the base directory.
+	AllowSymlinksOutsideOfBase bool
+
+	// Mounts contains additional boxes in format <prefix>=<box file or base directory>.
+	Mounts cli.StringSlice
+
+	// ReloadOnChange reloads the boxes as soon as one of the box files changes.
+	ReloadOnChange bool
 }
 
 func NewInitiatorFor(app *cli.App) *Initiator {
@@ -79,31 +89,42 @@ func InitiatorBaseConfigureCli(instance *Initiator) error {
 		instance.App.Description = `Either serves the content of the given [box file] or from a given [base directory].
    If nothing is provided the current work directory is assumed as base directory.`
 		instance.App.ArgsUsage = "[box files or base directories]"
+		instance.App.Flags = append(instance.App.Flags, cli.BoolFlag{
+			Name:        "allowSymlinksOutsideOfBase",
+			Usage:       "Serves also files of base directories which symlinks are pointing outside of the base directory.",
+			Destination: &instance.AllowSymlinksOutsideOfBase,
+		}, cli.BoolFlag{
+			Name: "dev",
+			Usage: "Enables the development mode: Changes of the base directories are pushed to the browser" +
+				"\n     which reloads the page. Served HTML files will contain the required reload script.",
+			Destination: &instance.Server.Dev,
+		}, cli.StringSliceFlag{
+			Name: "mount",
+			Usage: "Mounts a box file or base directory under the given path prefix in format <prefix>=<box file or base directory>." +
+				"\n     Example: --mount /docs=docs.box --mount /app=./app/dist",
+			Value: &instance.Mounts,
+		}, cli.BoolFlag{
+			Name: "reloadOnChange",
+			Usage: "Reloads the boxes as soon as one of the given box files changes. Independent of this the boxes" +
+				"\n     are also reloaded on SIGHUP and on 'POST /box/reload' at the --adminAddress.",
+			Destination: &instance.ReloadOnChange,
+		})
 		oldBefore := instance.App.Before
 		instance.App.Before = func(ctx *cli.Context) error {
 			if err := oldBefore(ctx); err != nil {
 				return err
 			}
-			if ctx.NArg() > 0 {
-				var cb goxr.CombinedBox
-				for _, base := range ctx.Args() {
-					if box, err := packed.OpenBox(base); err == nil {
-						cb = cb.With(box)
-					} else if !common.IsDoesNotContainBox(err) {
-						return err
-					} else if box, err := fs.OpenBox(base); err != nil {
-						return err
-					} else {
-						cb = cb.With(box)
-					}
-				}
-				instance.Server.Box = cb
+			bases := ctx.Args()
+			if box, err := instance.openBoxes(bases); err != nil {
+				return err
 			} else {
-				if box, err := fs.OpenBox("."); err != nil {
-					return err
-				} else {
-					instance.Server.Box = box
-				}
+				instance.Server.Box = box
+			}
+			instance.Server.OpenBox = func() (goxr.Box, error) {
+				return instance.openBoxes(bases)
+			}
+			if instance.ReloadOnChange {
+				instance.Server.WatchBoxFiles = instance.boxFilesOf(bases)
 			}
 			if c, err := configuration.OfBox(instance.Server.Box); err != nil {
 				return err
@@ -116,6 +137,71 @@ func InitiatorBaseConfigureCli(instance *Initiator) error {
 	return nil
 }
 
+func (instance *Initiator) openBoxes(bases []string) (goxr.Box, error) {
+	var mb goxr.MountBox
+	if len(bases) > 0 {
+		var cb goxr.CombinedBox
+		for _, base := range bases {
+			if box, err := instance.openPackedOrFsBox(base); err != nil {
+				return nil, err
+			} else {
+				cb = cb.With(box)
+			}
+		}
+		if len(instance.Mounts) == 0 {
+			return cb, nil
+		}
+		mb = mb.With("", cb)
+	} else if len(instance.Mounts) == 0 {
+		return instance.openFsBox(".")
+	}
+	for _, plain := range instance.Mounts {
+		if prefix, base, err := goxr.ParseMount(plain); err != nil {
+			return nil, err
+		} else if box, err := instance.openPackedOrFsBox(base); err != nil {
+			return nil, err
+		} else {
+			mb = mb.With(prefix, box)
+		}
+	}
+	return mb, nil
+}
+
+func (instance *Initiator) boxFilesOf(bases []string) (result []string) {
+	candidates := append([]string{}, bases...)
+	for _, plain := range instance.Mounts {
+		if _, base, err := goxr.ParseMount(plain); err == nil {
+			candidates = append(candidates, base)
+		}
+	}
+	for _, candidate := range candidates {
+		if fi, err := os.Stat(candidate); err == nil && !fi.IsDir() {
+			result = append(result, candidate)
+		}
+	}
+	return
+}
+
+func (instance *Initiator) openPackedOrFsBox(base string) (goxr.Box, error) {
+	if box, err := goxr.OpenPackedOrFsBox(base); err != nil {
+		return nil, err
+	} else if fsBox, ok := box.(*fs.Box); ok {
+		fsBox.Strict = !instance.AllowSymlinksOutsideOfBase
+		return fsBox, nil
+	} else {
+		return box, nil
+	}
+}
+
+func (instance *Initiator) openFsBox(base string) (*fs.Box, error) {
+	if box, err := fs.OpenBox(base); err != nil {
+		return nil, err
+	} else {
+		box.Strict = !instance.AllowSymlinksOutsideOfBase
+		return box, nil
+	}
+}
+
 func InitiatorConfigureCliAction(instance *Initiator) error {
 	instance.App.Action = func(ctx *cli.Context) error {
 		return instance.Server.Run()
@@ -3,6 +3,7 @@ package server
 import (
 	"github.com/echocat/goxr"
 	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
@@ -101,3 +102,5 @@ func (instance *testInterceptor) OnHandleError(box goxr.Box, err error, _ bool,
 func (instance *testInterceptor) OnAccessLog(goxr.Box, *fasthttp.RequestCtx, *map[string]interface{}) (handled bool) {
 	return false
 }
+
+func (instance *testInterceptor) OnWriteHeadersFor(goxr.Box, *fasthttp.RequestCtx, common.FileInfo) {}
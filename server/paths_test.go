@@ -0,0 +1,120 @@
+package server
+
+import (
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/box/packed"
+	"github.com/echocat/goxr/server/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Server_pathsDenied(t *testing.T) {
+	serve := func(s *Server, path string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		s.Handle(ctx)
+		return ctx
+	}
+
+	t.Run("default excludes", func(t *testing.T) {
+		s := &Server{Box: openTestBase1ForT(t)}
+		assert.NoError(t, s.configure())
+
+		assert.Equal(t, http.StatusOK, serve(s, "/index.html").Response.StatusCode())
+		assert.Equal(t, http.StatusOK, serve(s, "/").Response.StatusCode())
+		assert.Equal(t, http.StatusNotFound, serve(s, "/"+configuration.LocationInBox).Response.StatusCode())
+		assert.Equal(t, http.StatusNotFound, serve(s, "//foo/../"+configuration.LocationInBox).Response.StatusCode())
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		code := http.StatusForbidden
+		s := &Server{Box: openTestBase1ForT(t)}
+		s.Configuration.Paths.DeniedStatusCode = &code
+		assert.NoError(t, s.configure())
+
+		assert.Equal(t, http.StatusForbidden, serve(s, "/"+configuration.LocationInBox).Response.StatusCode())
+	})
+
+	t.Run("includes", func(t *testing.T) {
+		includes := []string{`^/static/`}
+		s := &Server{Box: openTestBase1ForT(t)}
+		s.Configuration.Paths.Includes = &includes
+		assert.NoError(t, s.configure())
+
+		assert.Equal(t, http.StatusNotFound, serve(s, "/index.html").Response.StatusCode())
+	})
+
+	t.Run("denied targets are invalid", func(t *testing.T) {
+		index := "/index.html"
+		excludes := []string{`^/index\.html$`}
+		s := &Server{Box: openTestBase1ForT(t)}
+		s.Configuration.Paths.Index = &index
+		s.Configuration.Paths.Excludes = &excludes
+		assert.Error(t, s.configure())
+	})
+
+	t.Run("unsupported denied status code", func(t *testing.T) {
+		code := http.StatusTeapot
+		s := &Server{Box: openTestBase1ForT(t)}
+		s.Configuration.Paths.DeniedStatusCode = &code
+		assert.Error(t, s.configure())
+	})
+}
+
+func Test_Server_pathsDeniedThroughLinks(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-paths-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+
+	serve := func(box goxr.Box, path string) int {
+		s := &Server{Box: box}
+		assert.NoError(t, s.configure())
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		s.Handle(ctx)
+		return ctx.Response.StatusCode()
+	}
+
+	t.Run("packed", func(t *testing.T) {
+		fn := filepath.Join(root, "box.goxr")
+		writer, err := packed.NewWriter(fn, packed.OpenModeCreateOnly, packed.WriteModeNewOnly)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Write(packed.TargetEntry{Filename: "index.html"}, strings.NewReader("<html></html>")))
+		assert.NoError(t, writer.Write(packed.TargetEntry{Filename: configuration.LocationInBox}, strings.NewReader("secret: true")))
+		assert.NoError(t, writer.WriteLink(packed.TargetEntry{Filename: "config.txt"}, configuration.LocationInBox))
+		assert.NoError(t, writer.Close())
+		box, err := packed.OpenBox(fn)
+		assert.NoError(t, err)
+		defer func() {
+			assert.NoError(t, box.Close())
+		}()
+
+		assert.Equal(t, http.StatusOK, serve(box, "/index.html"))
+		assert.Equal(t, http.StatusNotFound, serve(box, "/config.txt"))
+		assert.Equal(t, http.StatusNotFound, serve(goxr.MountBox{}.With("static", box), "/static/config.txt"))
+	})
+
+	t.Run("fs", func(t *testing.T) {
+		base := filepath.Join(root, "base")
+		assert.NoError(t, os.MkdirAll(base, 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(base, configuration.LocationInBox), []byte("secret: true"), 0644))
+		assert.NoError(t, os.Symlink(configuration.LocationInBox, filepath.Join(base, "config.txt")))
+
+		box, err := fs.OpenBox(base)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, serve(box, "/config.txt"))
+
+		box, err = fs.OpenStrictBox(base)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, serve(box, "/config.txt"))
+	})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/valyala/fasthttp"
+)
+
+// NegotiatePrecompressed replaces the given file with its precompressed
+// sibling (for example index.html.br for index.html) if there is one which is
+// accepted by the client. In this case Content-Encoding is set and the given
+// file is closed. If there is at least one sibling Vary: Accept-Encoding is
+// set, because the response depends on the client. Siblings which are not
+// allowed to be served (see CheckPathAllowed) are ignored.
+func (instance *Server) NegotiatePrecompressed(box goxr.Box, path string, f common.File, fi common.FileInfo, ctx *fasthttp.RequestCtx) (common.File, common.FileInfo) {
+	if !instance.Configuration.Response.GetPrecompressed() || instance.shouldInjectDevScript(fi) {
+		return f, fi
+	}
+	if _, encoded := common.EncodingOfFilename(path); encoded {
+		return f, fi
+	}
+
+	var candidates []common.Encoding
+	for _, encoding := range common.Encodings {
+		if instance.CheckPathAllowed(path+encoding.Extension()) != nil {
+			continue
+		} else if vfi, err := box.Info(path + encoding.Extension()); err == nil && !vfi.IsDir() {
+			candidates = append(candidates, encoding)
+		}
+	}
+	if len(candidates) == 0 {
+		return f, fi
+	}
+	addVary(ctx, "Accept-Encoding")
+
+	encoding, ok := common.SelectEncoding(string(ctx.Request.Header.Peek("Accept-Encoding")), candidates)
+	if !ok {
+		return f, fi
+	}
+	vf, err := box.Open(path + encoding.Extension())
+	if err != nil {
+		return f, fi
+	}
+	vfi, err := vf.GetFileInfo()
+	if err != nil {
+		_ = vf.Close()
+		return f, fi
+	}
+	_ = f.Close()
+	ctx.Response.Header.Set("Content-Encoding", encoding.String())
+	return vf, &encodedFileInfo{FileInfo: fi, encoding: encoding, encoded: vfi}
+}
+
+// encodedFileInfo describes the original file (name, type, modification time)
+// but the size and checksum of its encoded sibling, so each encoding gets its
+// own ETag.
+type encodedFileInfo struct {
+	common.FileInfo
+	encoding common.Encoding
+	encoded  common.FileInfo
+}
+
+func (instance *encodedFileInfo) Size() int64 {
+	return instance.encoded.Size()
+}
+
+func (instance *encodedFileInfo) ChecksumString() string {
+	if efi, ok := instance.encoded.(common.ExtendedFileInfo); ok && efi.ChecksumString() != "" {
+		return efi.ChecksumString()
+	}
+	if efi, ok := instance.FileInfo.(common.ExtendedFileInfo); ok && efi.ChecksumString() != "" {
+		return efi.ChecksumString() + "-" + instance.encoding.String()
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"github.com/echocat/goxr/box/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Server_precompressed(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-precompressed-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html"), []byte("<p>plain</p>"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html.gz"), []byte("gzipped"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html.br"), []byte("brotli"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "other.html"), []byte("<p>other</p>"), 0644))
+	box, err := fs.OpenBox(root)
+	assert.NoError(t, err)
+
+	s := &Server{Box: box}
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	request := func(path, acceptEncoding string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		if acceptEncoding != "" {
+			ctx.Request.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		s.Handle(ctx)
+		return ctx
+	}
+
+	plain := request("/index.html", "")
+	assert.Equal(t, http.StatusOK, plain.Response.StatusCode())
+	assert.Equal(t, "<p>plain</p>", string(plain.Response.Body()))
+	assert.Equal(t, "", string(plain.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, "Accept-Encoding", string(plain.Response.Header.Peek("Vary")))
+
+	gzipped := request("/index.html", "gzip, deflate")
+	assert.Equal(t, http.StatusOK, gzipped.Response.StatusCode())
+	assert.Equal(t, "gzipped", string(gzipped.Response.Body()))
+	assert.Equal(t, "gzip", string(gzipped.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, "text/html; charset=utf-8", string(gzipped.Response.Header.ContentType()))
+	assert.NotEqual(t, string(plain.Response.Header.Peek("Etag")), string(gzipped.Response.Header.Peek("Etag")))
+
+	brotli := request("/index.html", "gzip, br")
+	assert.Equal(t, "brotli", string(brotli.Response.Body()))
+	assert.Equal(t, "br", string(brotli.Response.Header.Peek("Content-Encoding")))
+
+	preferred := request("/index.html", "gzip, br;q=0.5")
+	assert.Equal(t, "gzip", string(preferred.Response.Header.Peek("Content-Encoding")))
+
+	other := request("/other.html", "gzip, br")
+	assert.Equal(t, "<p>other</p>", string(other.Response.Body()))
+	assert.Equal(t, "", string(other.Response.Header.Peek("Content-Encoding")))
+	assert.Equal(t, "", string(other.Response.Header.Peek("Vary")))
+
+	disabled := false
+	s.Configuration.Response.Precompressed = &disabled
+	off := request("/index.html", "gzip, br")
+	assert.Equal(t, "<p>plain</p>", string(off.Response.Body()))
+	assert.Equal(t, "", string(off.Response.Header.Peek("Content-Encoding")))
+}
+
+func Test_Server_precompressed_excluded(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-precompressed-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html"), []byte("<p>plain</p>"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html.br"), []byte("brotli"), 0644))
+	box, err := fs.OpenBox(root)
+	assert.NoError(t, err)
+
+	s := &Server{Box: box}
+	s.Configuration.Paths.Excludes = &[]string{`\.br$`}
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/index.html")
+	ctx.Request.Header.Set("Accept-Encoding", "br")
+	s.Handle(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, "<p>plain</p>", string(ctx.Response.Body()))
+	assert.Equal(t, "", string(ctx.Response.Header.Peek("Content-Encoding")))
+}
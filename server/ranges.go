@@ -0,0 +1,217 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr/common"
+	"github.com/valyala/fasthttp"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	sPath "path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidRange = errors.New("invalid range")
+	errNoOverlap    = errors.New("invalid range: failed to overlap")
+)
+
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (instance byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", instance.start, instance.start+instance.length-1, size)
+}
+
+// RangesFor returns the ranges requested by the Range header. It returns no
+// ranges if the whole file should be served: the header is absent, the
+// If-Range precondition does not match or the ranges are covering more than
+// the whole file. If the ranges cannot be satisfied false is returned.
+func (instance *Server) RangesFor(fi common.FileInfo, ctx *fasthttp.RequestCtx) ([]byteRange, bool) {
+	header := string(ctx.Request.Header.Peek("Range"))
+	if header == "" || !(ctx.IsGet() || ctx.IsHead()) || !instance.doesIfRangeMatch(fi, ctx) {
+		return nil, true
+	}
+	ranges, err := parseRanges(header, fi.Size())
+	if err != nil {
+		return nil, false
+	}
+	var sum int64
+	for _, r := range ranges {
+		sum += r.length
+	}
+	if sum > fi.Size() {
+		return nil, true
+	}
+	return ranges, true
+}
+
+func (instance *Server) doesIfRangeMatch(fi common.FileInfo, ctx *fasthttp.RequestCtx) bool {
+	ifRange := string(ctx.Request.Header.Peek("If-Range"))
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, `W/`) {
+		efi, ok := fi.(common.ExtendedFileInfo)
+		return ok && instance.Configuration.Response.GetWithEtag() &&
+			efi.ChecksumString() != "" && ifRange == fmt.Sprintf(`"%s"`, efi.ChecksumString())
+	}
+	if t, err := http.ParseTime(ifRange); err == nil {
+		return t.Truncate(time.Second).Equal(fi.ModTime().Truncate(time.Second))
+	}
+	return false
+}
+
+// RangeNotSatisfiableFor responds with 416 (Range Not Satisfiable).
+func (instance *Server) RangeNotSatisfiableFor(fi common.FileInfo, ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", fi.Size()))
+	ctx.Response.ResetBody()
+	ctx.Response.Header.Del("Content-Type")
+	ctx.Response.SetStatusCode(http.StatusRequestedRangeNotSatisfiable)
+}
+
+// ServeRanges responds with 206 (Partial Content) and the given ranges of the
+// file. Multiple ranges are served as multipart/byteranges.
+func (instance *Server) ServeRanges(f common.File, fi common.FileInfo, ranges []byteRange, ctx *fasthttp.RequestCtx) error {
+	size := fi.Size()
+	ctx.Response.SetStatusCode(http.StatusPartialContent)
+	if len(ranges) == 1 {
+		r := ranges[0]
+		if _, err := f.Seek(r.start, io.SeekStart); err != nil {
+			return err
+		}
+		ctx.Response.Header.Set("Content-Range", r.contentRange(size))
+		ctx.Response.SetBodyStream(&readCloser{Reader: io.LimitReader(f, r.length), Closer: f}, int(r.length))
+		return nil
+	}
+
+	contentType := ""
+	if instance.Configuration.Response.GetWithContentType() {
+		contentType = mime.TypeByExtension(sPath.Ext(fi.Name()))
+	}
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+	readers := make([]io.Reader, 0, len(ranges)*2+1)
+	var length int64
+	for i, r := range ranges {
+		header := fmt.Sprintf("--%s\r\n", boundary)
+		if i > 0 {
+			header = "\r\n" + header
+		}
+		if contentType != "" {
+			header += fmt.Sprintf("Content-Type: %s\r\n", contentType)
+		}
+		header += fmt.Sprintf("Content-Range: %s\r\n\r\n", r.contentRange(size))
+		readers = append(readers, strings.NewReader(header), &fileSection{file: f, byteRange: r})
+		length += int64(len(header)) + r.length
+	}
+	trailer := fmt.Sprintf("\r\n--%s--\r\n", boundary)
+	readers = append(readers, strings.NewReader(trailer))
+	length += int64(len(trailer))
+
+	ctx.Response.Header.SetContentType("multipart/byteranges; boundary=" + boundary)
+	ctx.Response.SetBodyStream(&readCloser{Reader: io.MultiReader(readers...), Closer: f}, int(length))
+	return nil
+}
+
+// parseRanges parses a Range header like "bytes=0-99,200-" for content of the
+// given size. Ranges which start after the end of the content are ignored.
+// If none of the ranges overlaps the content errNoOverlap is returned.
+func parseRanges(header string, size int64) ([]byteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errInvalidRange
+	}
+	var result []byteRange
+	noOverlap := false
+	for _, plain := range strings.Split(header[len(prefix):], ",") {
+		plain = strings.TrimSpace(plain)
+		if plain == "" {
+			continue
+		}
+		i := strings.IndexByte(plain, '-')
+		if i < 0 {
+			return nil, errInvalidRange
+		}
+		start, end := strings.TrimSpace(plain[:i]), strings.TrimSpace(plain[i+1:])
+		var r byteRange
+		if start == "" {
+			// suffix range: the last n bytes
+			if end == "" || end[0] == '-' {
+				return nil, errInvalidRange
+			}
+			n, err := strconv.ParseInt(end, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			r.start = size - n
+			r.length = size - r.start
+		} else {
+			s, err := strconv.ParseInt(start, 10, 64)
+			if err != nil || s < 0 {
+				return nil, errInvalidRange
+			}
+			if s >= size {
+				noOverlap = true
+				continue
+			}
+			r.start = s
+			if end == "" {
+				r.length = size - r.start
+			} else {
+				e, err := strconv.ParseInt(end, 10, 64)
+				if err != nil || r.start > e {
+					return nil, errInvalidRange
+				}
+				if e >= size {
+					e = size - 1
+				}
+				r.length = e - r.start + 1
+			}
+		}
+		result = append(result, r)
+	}
+	if noOverlap && len(result) == 0 {
+		return nil, errNoOverlap
+	} else if len(result) == 0 {
+		return nil, errInvalidRange
+	}
+	return result, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// fileSection reads the given range of the file. The file is positioned on
+// the first read, so multiple sections of the same file can be read one after
+// another.
+type fileSection struct {
+	file common.File
+	byteRange
+	reader io.Reader
+}
+
+func (instance *fileSection) Read(p []byte) (int, error) {
+	if instance.reader == nil {
+		if _, err := instance.file.Seek(instance.start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		instance.reader = io.LimitReader(instance.file, instance.length)
+	}
+	return instance.reader.Read(p)
+}
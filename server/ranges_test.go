@@ -0,0 +1,126 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_parseRanges(t *testing.T) {
+	cases := []struct {
+		header   string
+		expected []byteRange
+		err      error
+	}{
+		{"bytes=0-9", []byteRange{{0, 10}}, nil},
+		{"bytes=10-", []byteRange{{10, 90}}, nil},
+		{"bytes=-10", []byteRange{{90, 10}}, nil},
+		{"bytes=-200", []byteRange{{0, 100}}, nil},
+		{"bytes=90-200", []byteRange{{90, 10}}, nil},
+		{"bytes=0-0, 5-9", []byteRange{{0, 1}, {5, 5}}, nil},
+		{"bytes=0-9,200-300", []byteRange{{0, 10}}, nil},
+		{"bytes=200-300", nil, errNoOverlap},
+		{"bytes=-0", nil, errNoOverlap},
+		{"bytes=9-0", nil, errInvalidRange},
+		{"bytes=a-9", nil, errInvalidRange},
+		{"bytes=", nil, errInvalidRange},
+		{"items=0-9", nil, errInvalidRange},
+	}
+	for _, c := range cases {
+		t.Run(c.header, func(t *testing.T) {
+			actual, err := parseRanges(c.header, 100)
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func Test_Server_ranges(t *testing.T) {
+	s := &Server{Box: openTestBase1ForT(t)}
+	assert.NoError(t, s.configure())
+	full, err := ioutil.ReadFile("../resources/testBase1/index.html")
+	assert.NoError(t, err)
+	size := len(full)
+
+	serve := func(header ...string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/index.html")
+		for i := 0; i+1 < len(header); i += 2 {
+			ctx.Request.Header.Set(header[i], header[i+1])
+		}
+		s.Handle(ctx)
+		return ctx
+	}
+
+	t.Run("without range", func(t *testing.T) {
+		ctx := serve()
+		assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+		assert.Equal(t, "bytes", string(ctx.Response.Header.Peek("Accept-Ranges")))
+		assert.Equal(t, string(full), string(ctx.Response.Body()))
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		ctx := serve("Range", "bytes=2-5")
+		assert.Equal(t, http.StatusPartialContent, ctx.Response.StatusCode())
+		assert.Equal(t, "bytes 2-5/"+strconv.Itoa(size), string(ctx.Response.Header.Peek("Content-Range")))
+		assert.Equal(t, string(full[2:6]), string(ctx.Response.Body()))
+	})
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		ctx := serve("Range", "bytes=0-1,-3")
+		assert.Equal(t, http.StatusPartialContent, ctx.Response.StatusCode())
+		mediaType, params, err := mime.ParseMediaType(string(ctx.Response.Header.ContentType()))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/byteranges", mediaType)
+		body := ctx.Response.Body()
+		assert.Equal(t, len(body), ctx.Response.Header.ContentLength())
+
+		reader := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+		part, err := reader.NextPart()
+		assert.NoError(t, err)
+		assert.Equal(t, "bytes 0-1/"+strconv.Itoa(size), part.Header.Get("Content-Range"))
+		assert.Equal(t, "text/html; charset=utf-8", part.Header.Get("Content-Type"))
+		b, err := ioutil.ReadAll(part)
+		assert.NoError(t, err)
+		assert.Equal(t, string(full[:2]), string(b))
+
+		part, err = reader.NextPart()
+		assert.NoError(t, err)
+		assert.Equal(t, "bytes "+strconv.Itoa(size-3)+"-"+strconv.Itoa(size-1)+"/"+strconv.Itoa(size), part.Header.Get("Content-Range"))
+		b, err = ioutil.ReadAll(part)
+		assert.NoError(t, err)
+		assert.Equal(t, string(full[size-3:]), string(b))
+
+		_, err = reader.NextPart()
+		assert.Error(t, err)
+	})
+
+	t.Run("not satisfiable", func(t *testing.T) {
+		ctx := serve("Range", "bytes="+strconv.Itoa(size+10)+"-")
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, ctx.Response.StatusCode())
+		assert.Equal(t, "bytes */"+strconv.Itoa(size), string(ctx.Response.Header.Peek("Content-Range")))
+	})
+
+	t.Run("if-range matches", func(t *testing.T) {
+		etag := string(serve().Response.Header.Peek("Etag"))
+		assert.NotEmpty(t, etag)
+		ctx := serve("Range", "bytes=0-0", "If-Range", etag)
+		assert.Equal(t, http.StatusPartialContent, ctx.Response.StatusCode())
+		assert.Equal(t, string(full[:1]), string(ctx.Response.Body()))
+	})
+
+	t.Run("if-range does not match", func(t *testing.T) {
+		ctx := serve("Range", "bytes=0-0", "If-Range", `"other"`)
+		assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+		assert.Equal(t, string(full), string(ctx.Response.Body()))
+
+		ctx = serve("Range", "bytes=0-0", "If-Range", "Mon, 02 Jan 2006 15:04:05 GMT")
+		assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	})
+}
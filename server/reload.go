@@ -0,0 +1,304 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/slf4g"
+	"github.com/valyala/fasthttp"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	AdminReloadPath = "/box/reload"
+
+	DefaultWatchBoxFilesInterval = 2 * time.Second
+)
+
+var ErrReloadNotSupported = errors.New("reload of box is not supported")
+
+// Reload opens a new version of the box using OpenBox and serves it afterwards
+// instead of the current one (see SwapBox).
+func (instance *Server) Reload() error {
+	if instance.OpenBox == nil {
+		return ErrReloadNotSupported
+	}
+	box, err := instance.OpenBox()
+	if err != nil {
+		return err
+	}
+	if err := instance.SwapBox(box); err != nil {
+		_ = box.Close()
+		return err
+	}
+	return nil
+}
+
+// SwapBox validates the configuration against the given box and serves it
+// afterwards instead of the current one. Requests which are currently handled
+// are not affected. The replaced box is closed as soon as all of its files
+// which are still in use (for example while their content is sent) are closed.
+// If the validation fails the current box remains untouched. In dev mode the
+// changes of the given box are watched instead of the ones of the current box.
+func (instance *Server) SwapBox(box goxr.Box) error {
+	instance.reloadMutex.Lock()
+	defer instance.reloadMutex.Unlock()
+
+	c := instance.Configuration
+	if err := c.ValidateAndSummarize(box); err != nil {
+		return err
+	}
+	replacement := newLeasedBox(c.Cache.Wrap(box), instance.Log())
+	if reloader := instance.dev; reloader != nil {
+		if err := instance.watchDev(reloader, replacement); err != nil {
+			return err
+		}
+	}
+
+	instance.boxMutex.Lock()
+	old := instance.Box
+	instance.Box = replacement
+	instance.Configuration = c
+	instance.boxMutex.Unlock()
+
+	instance.Log().
+		With("event", "boxSwapped").
+		Info("Box swapped.")
+
+	if instance.Configuration.Listen.GetHttpsAddress() != "" && instance.hasBoxCertificates() {
+		instance.reloadCertificatesAndLog("boxSwapped")
+	}
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func (instance *Server) reloadAndLog(cause string) {
+	if err := instance.Reload(); err != nil {
+		instance.Log().
+			With("event", "boxReloadFailed").
+			With("cause", cause).
+			WithError(err).
+			Warn("Cannot reload box; the previous version will be served.")
+	}
+}
+
+func (instance *Server) startReloadTriggers() (io.Closer, error) {
+	tlsEnabled := instance.Configuration.Listen.GetHttpsAddress() != ""
+	if instance.OpenBox == nil && !tlsEnabled {
+		return common.NewOnceCloser(func() error { return nil }), nil
+	}
+
+	done := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if instance.OpenBox != nil {
+					instance.reloadAndLog("signal")
+				}
+				if tlsEnabled {
+					instance.reloadCertificatesAndLog("signal")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if instance.OpenBox != nil && len(instance.WatchBoxFiles) > 0 {
+		interval := instance.WatchBoxFilesInterval
+		if interval <= 0 {
+			interval = DefaultWatchBoxFilesInterval
+		}
+		go watchFiles(instance.WatchBoxFiles, interval, done, func() {
+			instance.reloadAndLog("fileChanged")
+		})
+	}
+	if interval := instance.Configuration.Listen.Tls.GetReloadInterval(); tlsEnabled && interval > 0 {
+		if filenames := instance.certificateFiles(); len(filenames) > 0 {
+			go watchFiles(filenames, interval, done, func() {
+				instance.reloadCertificatesAndLog("fileChanged")
+			})
+		}
+	}
+
+	return common.NewOnceCloser(func() error {
+		signal.Stop(signals)
+		close(done)
+		return nil
+	}), nil
+}
+
+// watchFiles polls the given files with the given interval and calls onChange
+// if at least one of them changed.
+func watchFiles(filenames []string, interval time.Duration, done chan struct{}, onChange func()) {
+	states := make(map[string]string, len(filenames))
+	stateOf := func(filename string) string {
+		if fi, err := os.Stat(filename); err != nil {
+			return ""
+		} else {
+			return fmt.Sprintf("%d/%d", fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	for _, filename := range filenames {
+		states[filename] = stateOf(filename)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			changed := false
+			for filename, previous := range states {
+				if current := stateOf(filename); current != previous {
+					states[filename] = current
+					changed = changed || current != ""
+				}
+			}
+			if changed {
+				onChange()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// HandleAdmin serves the endpoints of the admin address.
+func (instance *Server) HandleAdmin(ctx *fasthttp.RequestCtx) {
+	var reload func() error
+	var message string
+	switch string(ctx.Path()) {
+	case AdminReloadPath:
+		reload, message = instance.Reload, "Box reloaded."
+	case AdminReloadTlsPath:
+		reload, message = instance.ReloadCertificates, "Certificates reloaded."
+	}
+
+	if reload == nil {
+		(JsonResponse{Code: http.StatusNotFound}).Serve(ctx, instance.Log())
+	} else if !ctx.IsPost() {
+		ctx.Response.Header.Set("Allow", http.MethodPost)
+		(JsonResponse{Code: http.StatusMethodNotAllowed}).Serve(ctx, instance.Log())
+	} else if err := reload(); err == ErrReloadNotSupported || err == ErrTlsNotEnabled {
+		(JsonResponse{Code: http.StatusNotImplemented, Details: err.Error()}).Serve(ctx, instance.Log())
+	} else if err != nil {
+		(JsonResponse{Code: http.StatusInternalServerError, Details: err.Error()}).Serve(ctx, instance.Log())
+	} else {
+		(JsonResponse{Code: http.StatusOK, Message: message}).Serve(ctx, instance.Log())
+	}
+}
+
+// leasedBox keeps track of the files of the wrapped box which are still open.
+// Closing it closes the wrapped box not before all of them are closed.
+type leasedBox struct {
+	box    goxr.Box
+	logger log.Logger
+
+	mutex   sync.Mutex
+	leases  int
+	retired bool
+	closed  bool
+}
+
+func newLeasedBox(box goxr.Box, logger log.Logger) *leasedBox {
+	if lb, ok := box.(*leasedBox); ok {
+		return lb
+	}
+	return &leasedBox{
+		box:    box,
+		logger: logger,
+	}
+}
+
+func (instance *leasedBox) Open(name string) (common.File, error) {
+	instance.mutex.Lock()
+	if instance.closed {
+		instance.mutex.Unlock()
+		return nil, common.NewPathError("open", name, common.ErrAlreadyClosed)
+	}
+	instance.leases++
+	instance.mutex.Unlock()
+
+	f, err := instance.box.Open(name)
+	if err != nil {
+		instance.release()
+		return nil, err
+	}
+	return &leasedFile{File: f, box: instance}, nil
+}
+
+func (instance *leasedBox) Info(name string) (common.FileInfo, error) {
+	return instance.box.Info(name)
+}
+
+func (instance *leasedBox) ForEach(predicate common.FilePredicate, callback func(common.FileInfo) error) error {
+	if ib, ok := instance.box.(goxr.Iterable); ok {
+		return ib.ForEach(predicate, callback)
+	}
+	return goxr.ErrBoxIterationNotSupported
+}
+
+func (instance *leasedBox) Watch(listener common.ChangeListener) (io.Closer, error) {
+	if wb, ok := instance.box.(goxr.Watchable); ok {
+		return wb.Watch(listener)
+	}
+	return nil, goxr.ErrBoxWatchNotSupported
+}
+
+func (instance *leasedBox) release() {
+	instance.mutex.Lock()
+	instance.leases--
+	closeNow := instance.retired && !instance.closed && instance.leases <= 0
+	instance.closed = instance.closed || closeNow
+	instance.mutex.Unlock()
+	if closeNow {
+		if err := instance.box.Close(); err != nil {
+			instance.logger.
+				With("event", "closeReplacedBox").
+				WithError(err).
+				Warn("Cannot close replaced box.")
+		}
+	}
+}
+
+func (instance *leasedBox) Close() error {
+	instance.mutex.Lock()
+	if instance.retired {
+		instance.mutex.Unlock()
+		return nil
+	}
+	instance.retired = true
+	closeNow := instance.leases <= 0
+	instance.closed = closeNow
+	instance.mutex.Unlock()
+	if closeNow {
+		return instance.box.Close()
+	}
+	return nil
+}
+
+type leasedFile struct {
+	common.File
+	box  *leasedBox
+	once sync.Once
+}
+
+func (instance *leasedFile) Close() error {
+	err := instance.File.Close()
+	instance.once.Do(instance.box.release)
+	return err
+}
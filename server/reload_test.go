@@ -0,0 +1,123 @@
+package server
+
+import (
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func Test_Server_SwapBox(t *testing.T) {
+	old := &closeRecordingBox{Box: openTestBase1ForT(t)}
+	s := Server{Box: old}
+	assert.NoError(t, s.configure())
+
+	f, err := s.Box.Open("index.html")
+	assert.NoError(t, err)
+
+	replacement := &closeRecordingBox{Box: openTestBase1ForT(t)}
+	assert.NoError(t, s.SwapBox(replacement))
+	assert.False(t, old.closed, "old box must not be closed while one of its files is still open")
+
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, b)
+	assert.NoError(t, f.Close())
+	assert.True(t, old.closed)
+	assert.False(t, replacement.closed)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/index.html")
+	s.Handle(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+
+	assert.NoError(t, s.Box.Close())
+	assert.False(t, replacement.closed, "box must not be closed while the response body is still pending")
+	ctx.Response.Reset()
+	assert.True(t, replacement.closed)
+}
+
+func Test_Server_SwapBox_invalid(t *testing.T) {
+	index := "index.html"
+	current := &closeRecordingBox{Box: openTestBase1ForT(t)}
+	s := Server{Box: current}
+	s.Configuration.Paths.Index = &index
+	assert.NoError(t, s.configure())
+	served := s.Box
+
+	emptyDir, err := ioutil.TempDir("", "goxr-server-reload-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(emptyDir))
+	}()
+	empty, err := fs.OpenBox(emptyDir)
+	assert.NoError(t, err)
+
+	assert.Error(t, s.SwapBox(empty))
+	assert.Equal(t, served, s.Box)
+	assert.False(t, current.closed)
+}
+
+func Test_Server_HandleAdmin(t *testing.T) {
+	s := Server{Box: openTestBase1ForT(t)}
+	assert.NoError(t, s.configure())
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(http.MethodPost)
+	ctx.Request.SetRequestURI(AdminReloadPath)
+	s.HandleAdmin(ctx)
+	assert.Equal(t, http.StatusNotImplemented, ctx.Response.StatusCode())
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(http.MethodPost)
+	ctx.Request.SetRequestURI(AdminReloadTlsPath)
+	s.HandleAdmin(ctx)
+	assert.Equal(t, http.StatusNotImplemented, ctx.Response.StatusCode())
+
+	reloads := 0
+	s.OpenBox = func() (goxr.Box, error) {
+		reloads++
+		return openTestBase1ForT(t), nil
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(AdminReloadPath)
+	s.HandleAdmin(ctx)
+	assert.Equal(t, http.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	assert.Equal(t, 0, reloads)
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(http.MethodPost)
+	ctx.Request.SetRequestURI(AdminReloadPath)
+	s.HandleAdmin(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, 1, reloads)
+}
+
+func openTestBase1ForT(t *testing.T) goxr.Box {
+	box, err := fs.OpenBox("../resources/testBase1")
+	assert.NoError(t, err)
+	return box
+}
+
+type closeRecordingBox struct {
+	goxr.Box
+	closed bool
+}
+
+func (instance *closeRecordingBox) Open(name string) (common.File, error) {
+	if instance.closed {
+		return nil, common.ErrAlreadyClosed
+	}
+	return instance.Box.Open(name)
+}
+
+func (instance *closeRecordingBox) Close() error {
+	instance.closed = true
+	return instance.Box.Close()
+}
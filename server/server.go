@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"github.com/echocat/goxr"
 	"github.com/echocat/goxr/common"
@@ -8,9 +9,11 @@ import (
 	"github.com/echocat/slf4g"
 	"github.com/valyala/fasthttp"
 	"mime"
+	"net"
 	"net/http"
 	"os"
 	sPath "path"
+	"sync"
 	"time"
 )
 
@@ -20,6 +23,26 @@ type Server struct {
 
 	Logger      log.Logger
 	Interceptor Interceptor
+
+	// Dev enables the development mode which requires a goxr.Watchable box. In this
+	// mode every served HTML file contains a script which reloads the page in the
+	// browser as soon as the content of the box changes.
+	Dev bool
+
+	// OpenBox opens a new version of the box on Reload. If nil reloading is not
+	// supported. Otherwise a SIGHUP triggers a Reload.
+	OpenBox func() (goxr.Box, error)
+	// WatchBoxFiles are polled for changes which triggers a Reload.
+	WatchBoxFiles []string
+	// WatchBoxFilesInterval is the interval WatchBoxFiles are polled with. If
+	// zero DefaultWatchBoxFilesInterval is used.
+	WatchBoxFilesInterval time.Duration
+
+	dev          *devReloader
+	compressed   compressionCache
+	certificates certificateStore
+	boxMutex     sync.RWMutex
+	reloadMutex  sync.Mutex
 }
 
 func (instance *Server) Run() error {
@@ -30,15 +53,61 @@ func (instance *Server) Run() error {
 			Handler:               instance.Handle,
 			NoDefaultServerHeader: true,
 		}
-		if instance.Configuration.Response.GetGzip() {
-			s.Handler = fasthttp.CompressHandler(s.Handler)
+		triggers, err := instance.startReloadTriggers()
+		if err != nil {
+			return err
+		}
+		//noinspection GoUnhandledErrorResult
+		defer triggers.Close()
+		if instance.dev != nil {
+			//noinspection GoUnhandledErrorResult
+			defer instance.dev.Close()
 		}
+
+		errs := make(chan error, 3)
+		if httpsAddress := instance.Configuration.Listen.GetHttpsAddress(); httpsAddress != "" {
+			ln, err := net.Listen("tcp", httpsAddress)
+			if err != nil {
+				return err
+			}
+			https := &fasthttp.Server{
+				Handler:               instance.Handle,
+				NoDefaultServerHeader: true,
+			}
+			instance.Log().
+				With("event", "httpsListenAndServe").
+				With("address", httpsAddress).
+				Debug()
+			go func() {
+				errs <- https.Serve(tls.NewListener(ln, instance.TlsConfig()))
+			}()
+			if instance.Configuration.Listen.GetHttpMode() == configuration.HttpModeRedirect {
+				s.Handler = instance.RedirectToHttps
+			}
+		}
+		if adminAddress := instance.Configuration.Listen.GetAdminAddress(); adminAddress != "" {
+			admin := &fasthttp.Server{
+				Handler:               instance.HandleAdmin,
+				NoDefaultServerHeader: true,
+			}
+			instance.Log().
+				With("event", "adminListenAndServe").
+				With("address", adminAddress).
+				Debug()
+			go func() {
+				errs <- admin.ListenAndServe(adminAddress)
+			}()
+		}
+
 		address := instance.Configuration.Listen.GetHttpAddress()
 		instance.Log().
 			With("event", "httpListenAndServe").
 			With("address", address).
 			Debug()
-		return s.ListenAndServe(address)
+		go func() {
+			errs <- s.ListenAndServe(address)
+		}()
+		return <-errs
 	}
 }
 
@@ -50,6 +119,9 @@ func (instance *Server) Validate() error {
 }
 
 func (instance *Server) Handle(ctx *fasthttp.RequestCtx) {
+	instance.boxMutex.RLock()
+	defer instance.boxMutex.RUnlock()
+
 	ctxToUse := ctx
 	boxToUse := instance.Box
 	if instance.Configuration.Logging.GetAccessLog() {
@@ -72,6 +144,9 @@ func (instance *Server) Handle(ctx *fasthttp.RequestCtx) {
 			}
 		}(start)
 	}
+	if instance.HandleDev(ctx) {
+		return
+	}
 	var handled bool
 	handled, boxToUse, ctxToUse = instance.onBeforeHandle(instance.Box, ctx)
 	defer instance.onAfterHandle(boxToUse, ctxToUse)
@@ -91,6 +166,7 @@ func (instance *Server) ResolveInitialTargetPath(box goxr.Box, ctx *fasthttp.Req
 			result = index
 		}
 	}
+	result = instance.Configuration.Paths.Fingerprints.Resolve(result)
 	return instance.onTargetPathResolved(box, result, ctx)
 }
 
@@ -98,7 +174,9 @@ func (instance *Server) ServeFile(box goxr.Box, path string, ctx *fasthttp.Reque
 	if statusCode <= 0 {
 		statusCode = http.StatusOK
 	}
-	if f, err := box.Open(path); err != nil {
+	if err := instance.CheckPathAllowed(path); err != nil {
+		instance.HandleError(box, err, interceptAllowed, ctx)
+	} else if f, err := box.Open(path); err != nil {
 		instance.HandleError(box, err, interceptAllowed, ctx)
 	} else {
 		success := false
@@ -110,19 +188,69 @@ func (instance *Server) ServeFile(box goxr.Box, path string, ctx *fasthttp.Reque
 
 		if fi, err := f.GetFileInfo(); err != nil {
 			instance.HandleError(box, err, interceptAllowed, ctx)
+		} else if err := instance.CheckPathAllowed(fi.Path()); err != nil {
+			// The box could have followed a link to another entry.
+			instance.HandleError(box, err, interceptAllowed, ctx)
 		} else if fi.IsDir() {
 			instance.HandleError(box, os.ErrNotExist, interceptAllowed, ctx)
-		} else if !instance.DoesETagMatched(box, fi, ctx) &&
+		} else if f, fi = instance.NegotiateEncoding(box, path, f, fi, ctx); !instance.DoesETagMatched(box, fi, ctx) &&
 			!instance.DoesModifiedMatched(box, fi, ctx) &&
 			!(interceptAllowed && instance.ShouldHandleStatusCode(box, statusCode, ctx)) {
 			instance.WriteFileHeadersFor(fi, ctx)
 			ctx.Response.SetStatusCode(statusCode)
+			if instance.shouldInjectDevScript(fi) {
+				// The checksum is the one of the content without the script.
+				ctx.Response.Header.Del("Etag")
+				if b, err := instance.injectDevScript(f); err != nil {
+					instance.HandleError(box, err, false, ctx)
+				} else {
+					ctx.Response.SetBody(b)
+				}
+				return
+			}
+			if statusCode == http.StatusOK {
+				ctx.Response.Header.Set("Accept-Ranges", "bytes")
+				if ranges, satisfiable := instance.RangesFor(fi, ctx); !satisfiable {
+					instance.RangeNotSatisfiableFor(fi, ctx)
+					return
+				} else if len(ranges) > 0 {
+					if err := instance.ServeRanges(f, fi, ranges, ctx); err != nil {
+						instance.HandleError(box, err, false, ctx)
+					} else {
+						success = true
+					}
+					return
+				}
+			}
+			if cfi, ok := fi.(*compressedFileInfo); ok {
+				success = true
+				if err := instance.ServeCompressed(f, cfi, ctx); err != nil {
+					ctx.Response.Header.Del("Content-Encoding")
+					instance.HandleError(box, err, false, ctx)
+				}
+				return
+			}
 			ctx.Response.SetBodyStream(f, int(fi.Size()))
 			success = true
 		}
 	}
 }
 
+// CheckPathAllowed returns an error if the given path is not allowed to be
+// served by paths.includes and paths.excludes. Depending on
+// paths.deniedStatusCode it is either a not exist or a permission error.
+func (instance *Server) CheckPathAllowed(path string) error {
+	if allowed, err := instance.Configuration.Paths.PathAllowed(path); err != nil {
+		return err
+	} else if allowed {
+		return nil
+	} else if instance.Configuration.Paths.GetDeniedStatusCode() == http.StatusForbidden {
+		return common.NewPathError("open", path, os.ErrPermission)
+	} else {
+		return common.NewPathError("open", path, os.ErrNotExist)
+	}
+}
+
 func (instance *Server) HandleError(box goxr.Box, err error, interceptAllowed bool, ctx *fasthttp.RequestCtx) {
 	handled, newErr, newCtx := instance.onHandleError(box, err, interceptAllowed, ctx)
 	if handled {
@@ -186,7 +314,9 @@ func (instance *Server) WriteGenericHeaders(ctx *fasthttp.RequestCtx) {
 
 func (instance *Server) WriteFileHeadersFor(fi common.FileInfo, ctx *fasthttp.RequestCtx) {
 	if efi, ok := fi.(common.ExtendedFileInfo); ok && instance.Configuration.Response.GetWithEtag() {
-		ctx.Response.Header.Set("Etag", fmt.Sprintf(`"%s"`, efi.ChecksumString()))
+		if checksum := efi.ChecksumString(); checksum != "" {
+			ctx.Response.Header.Set("Etag", fmt.Sprintf(`"%s"`, checksum))
+		}
 	}
 	if instance.Configuration.Response.GetWithLastModified() {
 		ctx.Response.Header.Set("Last-Modified", fi.ModTime().Truncate(time.Second).UTC().Format(time.RFC1123))
@@ -194,6 +324,9 @@ func (instance *Server) WriteFileHeadersFor(fi common.FileInfo, ctx *fasthttp.Re
 	if typ := mime.TypeByExtension(sPath.Ext(fi.Name())); typ != "" && instance.Configuration.Response.GetWithContentType() {
 		ctx.Response.Header.SetContentType(typ)
 	}
+	if fingerprints := instance.Configuration.Paths.Fingerprints; fingerprints.GetImmutable() && fingerprints.IsFingerprinted(fi.Path()) {
+		ctx.Response.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
 	instance.onWriteHeadersFor(instance.Box, ctx, fi)
 }
 
@@ -202,7 +335,7 @@ func (instance *Server) DoesETagMatched(box goxr.Box, fi common.FileInfo, ctx *f
 		return false
 	}
 	ifNonMatch := string(ctx.Request.Header.Peek("If-None-Match"))
-	if efi, ok := fi.(common.ExtendedFileInfo); ok && ifNonMatch == fmt.Sprintf(`"%s"`, efi.ChecksumString()) {
+	if efi, ok := fi.(common.ExtendedFileInfo); ok && ifNonMatch != "" && ifNonMatch == fmt.Sprintf(`"%s"`, efi.ChecksumString()) {
 		instance.NotModifiedFor(box, fi, ctx)
 		return true
 	}
@@ -250,7 +383,12 @@ func (instance *Server) configure() error {
 	if err := instance.configureMimeTypes(); err != nil {
 		return err
 	}
-	return nil
+	instance.Box = instance.Configuration.Cache.Wrap(instance.Box)
+	if err := instance.configureDev(); err != nil {
+		return err
+	}
+	instance.Box = newLeasedBox(instance.Box, instance.Log())
+	return instance.configureTls()
 }
 
 func (instance *Server) configureMimeTypes() error {
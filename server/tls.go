@@ -0,0 +1,250 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"github.com/echocat/goxr"
+	"github.com/echocat/goxr/server/configuration"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AdminReloadTlsPath = "/tls/reload"
+
+var (
+	ErrTlsNotEnabled  = errors.New("tls is not enabled")
+	ErrNoCertificates = errors.New("no certificates available")
+)
+
+// ReloadCertificates reads all certificates configured in listen.tls again
+// and serves them for all new connections afterwards. If one of them cannot
+// be read the current ones remain untouched.
+func (instance *Server) ReloadCertificates() error {
+	if instance.Configuration.Listen.GetHttpsAddress() == "" {
+		return ErrTlsNotEnabled
+	}
+	certificates, err := instance.loadCertificates()
+	if err != nil {
+		return err
+	}
+	instance.certificates.set(certificates)
+	instance.Log().
+		With("event", "certificatesLoaded").
+		With("amount", len(certificates)).
+		Debug()
+	return nil
+}
+
+func (instance *Server) reloadCertificatesAndLog(cause string) {
+	if err := instance.ReloadCertificates(); err != nil {
+		instance.Log().
+			With("event", "certificatesReloadFailed").
+			With("cause", cause).
+			WithError(err).
+			Warn("Cannot reload certificates; the previous ones will be served.")
+	}
+}
+
+func (instance *Server) configureTls() error {
+	if instance.Configuration.Listen.GetHttpsAddress() == "" {
+		return nil
+	}
+	return instance.ReloadCertificates()
+}
+
+func (instance *Server) loadCertificates() ([]*tls.Certificate, error) {
+	c := instance.Configuration.Listen
+	var result []*tls.Certificate
+	for _, certificate := range c.Tls.GetCertificates() {
+		if certPEM, keyPEM, err := instance.readCertificate(certificate); err != nil {
+			return nil, err
+		} else if pair, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			return nil, fmt.Errorf("cannot load certificate %s: %v", certificate.Certificate, err)
+		} else {
+			if pair.Leaf == nil {
+				if pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+					return nil, fmt.Errorf("cannot load certificate %s: %v", certificate.Certificate, err)
+				}
+			}
+			result = append(result, &pair)
+		}
+	}
+	if len(result) == 0 && c.Tls.GetSelfSigned() {
+		if selfSigned := instance.certificates.getSelfSigned(); selfSigned != nil {
+			result = append(result, selfSigned)
+		} else if selfSigned, err := generateSelfSignedCertificate(c.GetHttpsAddress()); err != nil {
+			return nil, err
+		} else {
+			instance.certificates.setSelfSigned(selfSigned)
+			result = append(result, selfSigned)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrNoCertificates
+	}
+	return result, nil
+}
+
+func (instance *Server) readCertificate(certificate configuration.Certificate) (certPEM, keyPEM []byte, err error) {
+	read := ioutil.ReadFile
+	if certificate.Location.IsBox() {
+		instance.boxMutex.RLock()
+		box := instance.Box
+		instance.boxMutex.RUnlock()
+		read = func(name string) ([]byte, error) {
+			return goxr.ReadFile(box, name)
+		}
+	}
+	if certPEM, err = read(certificate.Certificate); err != nil {
+		return nil, nil, err
+	}
+	if keyPEM, err = read(certificate.Key); err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+func (instance *Server) hasBoxCertificates() bool {
+	for _, certificate := range instance.Configuration.Listen.Tls.GetCertificates() {
+		if certificate.Location.IsBox() {
+			return true
+		}
+	}
+	return false
+}
+
+func (instance *Server) certificateFiles() (result []string) {
+	for _, certificate := range instance.Configuration.Listen.Tls.GetCertificates() {
+		if !certificate.Location.IsBox() {
+			result = append(result, certificate.Certificate, certificate.Key)
+		}
+	}
+	return
+}
+
+// TlsConfig returns the configuration used at listen.httpsAddress.
+func (instance *Server) TlsConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: instance.certificates.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// RedirectToHttps redirects every request to the same URI at
+// listen.httpsAddress.
+func (instance *Server) RedirectToHttps(ctx *fasthttp.RequestCtx) {
+	host := string(ctx.Host())
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		// IPv6 address without port
+		host = host[1 : len(host)-1]
+	}
+	if _, port, err := net.SplitHostPort(instance.Configuration.Listen.GetHttpsAddress()); err == nil && port != "" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	ctx.Response.Header.Set("Location", "https://"+host+string(ctx.RequestURI()))
+	ctx.Response.SetStatusCode(http.StatusPermanentRedirect)
+}
+
+// certificateStore holds the certificates currently served and selects the one
+// matching the requested server name (SNI) of a connection. The first
+// certificate is the fallback.
+type certificateStore struct {
+	mutex        sync.RWMutex
+	certificates []*tls.Certificate
+	selfSigned   *tls.Certificate
+}
+
+func (instance *certificateStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+	if len(instance.certificates) == 0 {
+		return nil, ErrNoCertificates
+	}
+	if hello.ServerName != "" {
+		for _, certificate := range instance.certificates {
+			if certificate.Leaf != nil && certificate.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return certificate, nil
+			}
+		}
+	}
+	return instance.certificates[0], nil
+}
+
+func (instance *certificateStore) set(certificates []*tls.Certificate) {
+	instance.mutex.Lock()
+	instance.certificates = certificates
+	instance.mutex.Unlock()
+}
+
+func (instance *certificateStore) getSelfSigned() *tls.Certificate {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+	return instance.selfSigned
+}
+
+func (instance *certificateStore) setSelfSigned(certificate *tls.Certificate) {
+	instance.mutex.Lock()
+	instance.selfSigned = certificate
+	instance.mutex.Unlock()
+}
+
+// generateSelfSignedCertificate creates a certificate for localhost and the
+// host of the given address which is valid for one year.
+func generateSelfSignedCertificate(address string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"goxr"}, CommonName: "localhost"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host, _, err := net.SplitHostPort(address); err == nil && host != "" && host != "localhost" {
+		if ip := net.ParseIP(host); ip == nil {
+			template.DNSNames = append(template.DNSNames, host)
+		} else if !ip.IsLoopback() && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
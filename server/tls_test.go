@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/echocat/goxr/server/configuration"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Server_certificates(t *testing.T) {
+	root, err := ioutil.TempDir("", "goxr-server-tls-test")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(root))
+	}()
+	writeCertificateForT(t, "a.example", filepath.Join(root, "a.pem"), filepath.Join(root, "a.key"))
+	writeCertificateForT(t, "b.example", filepath.Join(root, "b.pem"), filepath.Join(root, "b.key"))
+	box, err := fs.OpenBox(root)
+	assert.NoError(t, err)
+
+	s := &Server{Box: box}
+	s.Configuration.Listen.HttpsAddress = "127.0.0.1:0"
+	s.Configuration.Listen.Tls.CertificateFile = filepath.Join(root, "a.pem")
+	s.Configuration.Listen.Tls.KeyFile = filepath.Join(root, "a.key")
+	s.Configuration.Listen.Tls.Certificates = []configuration.Certificate{{
+		Certificate: "b.pem",
+		Key:         "b.key",
+		Location:    configuration.CertificateLocationBox,
+	}}
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	servedFor := func(serverName string) []string {
+		certificate, err := s.certificates.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		return certificate.Leaf.DNSNames
+	}
+	assert.Contains(t, servedFor("a.example"), "a.example")
+	assert.Contains(t, servedFor("b.example"), "b.example")
+	assert.Contains(t, servedFor("other.example"), "a.example")
+	assert.Contains(t, servedFor(""), "a.example")
+
+	for _, path := range []string{"/b.key", "/b.pem", "/./b.key"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		s.Handle(ctx)
+		assert.Equal(t, http.StatusNotFound, ctx.Response.StatusCode(), path)
+		assert.NotContains(t, string(ctx.Response.Body()), "PRIVATE KEY", path)
+	}
+
+	writeCertificateForT(t, "c.example", filepath.Join(root, "a.pem"), filepath.Join(root, "a.key"))
+	assert.NoError(t, s.ReloadCertificates())
+	assert.Contains(t, servedFor("c.example"), "c.example")
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "a.pem"), []byte("broken"), 0644))
+	assert.Error(t, s.ReloadCertificates())
+	assert.Contains(t, servedFor("c.example"), "c.example")
+}
+
+func Test_Server_selfSigned(t *testing.T) {
+	selfSigned := true
+	s := &Server{Box: openTestBase1ForT(t)}
+	s.Configuration.Listen.HttpsAddress = "127.0.0.1:0"
+	s.Configuration.Listen.Tls.SelfSigned = &selfSigned
+	assert.NoError(t, s.configure())
+	defer func() {
+		assert.NoError(t, s.Box.Close())
+	}()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := &fasthttp.Server{Handler: s.Handle}
+	go func() {
+		_ = server.Serve(tls.NewListener(ln, s.TlsConfig()))
+	}()
+	defer func() {
+		assert.NoError(t, server.Shutdown())
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/index.html")
+	if !assert.NoError(t, err) {
+		return
+	}
+	//noinspection GoUnhandledErrorResult
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"localhost"}, resp.TLS.PeerCertificates[0].DNSNames)
+
+	generated := s.certificates.getSelfSigned()
+	assert.NoError(t, s.ReloadCertificates())
+	assert.Equal(t, generated, s.certificates.getSelfSigned())
+}
+
+func Test_Server_RedirectToHttps(t *testing.T) {
+	cases := []struct {
+		httpsAddress string
+		host         string
+		expected     string
+	}{
+		{":8443", "example.org:8080", "https://example.org:8443/foo?bar=1"},
+		{":443", "example.org:8080", "https://example.org/foo?bar=1"},
+		{"0.0.0.0:443", "example.org", "https://example.org/foo?bar=1"},
+		{":443", "[::1]:8080", "https://[::1]/foo?bar=1"},
+		{":443", "[::1]", "https://[::1]/foo?bar=1"},
+		{":8443", "[::1]", "https://[::1]:8443/foo?bar=1"},
+	}
+	for _, c := range cases {
+		t.Run(c.httpsAddress+"/"+c.host, func(t *testing.T) {
+			s := &Server{}
+			s.Configuration.Listen.HttpsAddress = configuration.HttpsAddress(c.httpsAddress)
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/foo?bar=1")
+			ctx.Request.Header.SetHost(c.host)
+			s.RedirectToHttps(ctx)
+			assert.Equal(t, http.StatusPermanentRedirect, ctx.Response.StatusCode())
+			assert.Equal(t, c.expected, string(ctx.Response.Header.Peek("Location")))
+		})
+	}
+}
+
+func Test_Listen_Validate_tls(t *testing.T) {
+	s := &Server{Box: openTestBase1ForT(t)}
+	s.Configuration.Listen.HttpsAddress = ":8443"
+	assert.Error(t, s.Validate())
+
+	s.Configuration.Listen.HttpsAddress = ""
+	s.Configuration.Listen.HttpMode = configuration.HttpModeRedirect
+	assert.Error(t, s.Validate())
+
+	s.Configuration.Listen.HttpsAddress = ":8443"
+	s.Configuration.Listen.Tls.Certificates = []configuration.Certificate{{
+		Certificate: "missing.pem",
+		Key:         "missing.key",
+		Location:    configuration.CertificateLocationBox,
+	}}
+	assert.Error(t, s.Validate())
+}
+
+func writeCertificateForT(t *testing.T, host string, certificateFile, keyFile string) {
+	certificate, err := generateSelfSignedCertificate(host + ":443")
+	assert.NoError(t, err)
+	key, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(certificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0644))
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600))
+}
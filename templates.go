@@ -0,0 +1,246 @@
+package goxr
+
+import (
+	"github.com/echocat/goxr/common"
+	"github.com/echocat/goxr/entry"
+	"github.com/echocat/slf4g"
+	ht "html/template"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	tt "text/template"
+)
+
+// ParseTemplates parses all files of the box which are matching one of the
+// given patterns (see Glob) as html/template, like ParseHtmlTemplates does.
+//
+// text/template and html/template do not share a common type, so there is a
+// variant of every function for each of both packages: ParseTextTemplates,
+// ParseTextTemplatesInto and WatchTextTemplates for text/template and
+// ParseHtmlTemplates, ParseHtmlTemplatesInto and WatchHtmlTemplates for
+// html/template. This entry point uses html/template because it escapes its
+// output for the web.
+func ParseTemplates(box Box, patterns ...string) (*ht.Template, error) {
+	return ParseHtmlTemplates(box, patterns...)
+}
+
+// ParseTextTemplates parses all files of the box which are matching one of the
+// given patterns (see Glob) as text/template. Every template is named by its
+// path inside of the box. The first template is the root template.
+func ParseTextTemplates(box Box, patterns ...string) (*tt.Template, error) {
+	return ParseTextTemplatesInto(nil, box, patterns...)
+}
+
+// ParseTextTemplatesInto works like ParseTextTemplates but adds the templates
+// to the given one. This can be used to provide functions using Funcs(..).
+func ParseTextTemplatesInto(t *tt.Template, box Box, patterns ...string) (*tt.Template, error) {
+	if err := parseTemplates(box, patterns, func(name string, content string) error {
+		if t == nil {
+			t = tt.New(name)
+		}
+		tmpl := t
+		if name != t.Name() {
+			tmpl = t.New(name)
+		}
+		_, err := tmpl.Parse(content)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ParseHtmlTemplates parses all files of the box which are matching one of the
+// given patterns (see Glob) as html/template. Every template is named by its
+// path inside of the box. The first template is the root template.
+func ParseHtmlTemplates(box Box, patterns ...string) (*ht.Template, error) {
+	return ParseHtmlTemplatesInto(nil, box, patterns...)
+}
+
+// ParseHtmlTemplatesInto works like ParseHtmlTemplates but adds the templates
+// to the given one. This can be used to provide functions using Funcs(..).
+func ParseHtmlTemplatesInto(t *ht.Template, box Box, patterns ...string) (*ht.Template, error) {
+	if err := parseTemplates(box, patterns, func(name string, content string) error {
+		if t == nil {
+			t = ht.New(name)
+		}
+		tmpl := t
+		if name != t.Name() {
+			tmpl = t.New(name)
+		}
+		_, err := tmpl.Parse(content)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseTemplates(box Box, patterns []string, parse func(name string, content string) error) error {
+	names := make(map[string]bool)
+	for _, pattern := range patterns {
+		if matches, err := Glob(box, pattern); err != nil {
+			return err
+		} else {
+			for _, match := range matches {
+				names[match] = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		return common.NewPathError("parseTemplates", strings.Join(patterns, ", "), ErrNoTemplatesFound)
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		if content, err := ReadString(box, name); err != nil {
+			return err
+		} else if err := parse(name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TextTemplates provides text/templates parsed from a box. If the box is
+// Watchable the templates are parsed again as soon as one of them changes.
+type TextTemplates struct {
+	reloader *templatesReloader
+}
+
+// WatchTextTemplates parses the templates like ParseTextTemplatesInto. The
+// optional prepare function has to return a new template on every call which
+// is used as base of every parse (for example to provide functions).
+func WatchTextTemplates(box Box, prepare func() *tt.Template, patterns ...string) (*TextTemplates, error) {
+	if reloader, err := newTemplatesReloader(box, patterns, func() (interface{}, error) {
+		var t *tt.Template
+		if prepare != nil {
+			t = prepare()
+		}
+		return ParseTextTemplatesInto(t, box, patterns...)
+	}); err != nil {
+		return nil, err
+	} else {
+		return &TextTemplates{reloader}, nil
+	}
+}
+
+// Get returns the current version of the templates.
+func (instance *TextTemplates) Get() *tt.Template {
+	return instance.reloader.get().(*tt.Template)
+}
+
+func (instance *TextTemplates) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	return instance.Get().ExecuteTemplate(wr, name, data)
+}
+
+func (instance *TextTemplates) Close() error {
+	return instance.reloader.Close()
+}
+
+// HtmlTemplates provides html/templates parsed from a box. If the box is
+// Watchable the templates are parsed again as soon as one of them changes.
+type HtmlTemplates struct {
+	reloader *templatesReloader
+}
+
+// WatchHtmlTemplates parses the templates like ParseHtmlTemplatesInto. The
+// optional prepare function has to return a new template on every call which
+// is used as base of every parse (for example to provide functions).
+func WatchHtmlTemplates(box Box, prepare func() *ht.Template, patterns ...string) (*HtmlTemplates, error) {
+	if reloader, err := newTemplatesReloader(box, patterns, func() (interface{}, error) {
+		var t *ht.Template
+		if prepare != nil {
+			t = prepare()
+		}
+		return ParseHtmlTemplatesInto(t, box, patterns...)
+	}); err != nil {
+		return nil, err
+	} else {
+		return &HtmlTemplates{reloader}, nil
+	}
+}
+
+// Get returns the current version of the templates.
+func (instance *HtmlTemplates) Get() *ht.Template {
+	return instance.reloader.get().(*ht.Template)
+}
+
+func (instance *HtmlTemplates) ExecuteTemplate(wr io.Writer, name string, data interface{}) error {
+	return instance.Get().ExecuteTemplate(wr, name, data)
+}
+
+func (instance *HtmlTemplates) Close() error {
+	return instance.reloader.Close()
+}
+
+type templatesReloader struct {
+	parse   func() (interface{}, error)
+	mutex   sync.RWMutex
+	current interface{}
+	watcher io.Closer
+}
+
+func newTemplatesReloader(box Box, patterns []string, parse func() (interface{}, error)) (*templatesReloader, error) {
+	result := &templatesReloader{
+		parse: parse,
+	}
+	if current, err := parse(); err != nil {
+		return nil, err
+	} else {
+		result.current = current
+	}
+
+	if wb, ok := box.(Watchable); ok {
+		segments := make([][]string, len(patterns))
+		for i, pattern := range patterns {
+			segments[i] = strings.Split(entry.CleanPath(pattern), "/")
+		}
+		if watcher, err := wb.Watch(func(event common.ChangeEvent) {
+			candidate := strings.Split(entry.CleanPath(event.Path), "/")
+			for _, pattern := range segments {
+				if ok, _ := matchSegments(pattern, candidate); ok {
+					result.reload(event)
+					return
+				}
+			}
+		}); err != nil && err != ErrBoxWatchNotSupported {
+			return nil, err
+		} else {
+			result.watcher = watcher
+		}
+	}
+
+	return result, nil
+}
+
+func (instance *templatesReloader) reload(cause common.ChangeEvent) {
+	if current, err := instance.parse(); err != nil {
+		log.With("event", cause).
+			WithError(err).
+			Warn("Cannot parse changed templates; the previous version will be used.")
+	} else {
+		instance.mutex.Lock()
+		instance.current = current
+		instance.mutex.Unlock()
+	}
+}
+
+func (instance *templatesReloader) get() interface{} {
+	instance.mutex.RLock()
+	defer instance.mutex.RUnlock()
+	return instance.current
+}
+
+func (instance *templatesReloader) Close() error {
+	if watcher := instance.watcher; watcher != nil {
+		return watcher.Close()
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package goxr
+
+import (
+	"bytes"
+	"errors"
+	"github.com/echocat/goxr/box/fs"
+	"github.com/stretchr/testify/assert"
+	ht "html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseTemplates(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"templates/index.html":        `{{template "templates/parts/header.html" .}}<p>{{.}}</p>`,
+		"templates/parts/header.html": `<h1>{{upper .}}</h1>`,
+		"templates/mail.txt":          `Hello {{.}}!`,
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+
+	tmpl, err := ParseHtmlTemplatesInto(ht.New("").Funcs(ht.FuncMap{"upper": strings.ToUpper}), box, "templates/**/*.html")
+	assert.NoError(t, err)
+	buf := new(bytes.Buffer)
+	assert.NoError(t, tmpl.ExecuteTemplate(buf, "templates/index.html", "<b>"))
+	assert.Equal(t, `<h1>&lt;B&gt;</h1><p>&lt;b&gt;</p>`, buf.String())
+
+	textTmpl, err := ParseTextTemplates(box, "templates/*.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "templates/mail.txt", textTmpl.Name())
+	buf.Reset()
+	assert.NoError(t, textTmpl.Execute(buf, "<b>"))
+	assert.Equal(t, `Hello <b>!`, buf.String())
+
+	htmlTmpl, err := ParseTemplates(box, "templates/*.html")
+	assert.NoError(t, err)
+	assert.Equal(t, "templates/index.html", htmlTmpl.Name())
+
+	_, err = ParseTextTemplates(box, "*.foo")
+	assert.True(t, errors.Is(err, ErrNoTemplatesFound))
+}
+
+func Test_WatchTextTemplates(t *testing.T) {
+	dir := tempDirWithFilesForT(t, map[string]string{
+		"mail.txt": `Hello {{.}}!`,
+	})
+	defer removeAllForT(dir, t)
+	box, err := fs.OpenBox(dir)
+	assert.NoError(t, err)
+	box.WatchInterval = 10 * time.Millisecond
+
+	templates, err := WatchTextTemplates(box, nil, "*.txt")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, templates.Close())
+	}()
+
+	render := func() string {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, templates.ExecuteTemplate(buf, "mail.txt", "world"))
+		return buf.String()
+	}
+	assert.Equal(t, "Hello world!", render())
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mail.txt"), []byte(`Bye {{.}}!`), 0644))
+	assert.Eventually(t, func() bool {
+		return render() == "Bye world!"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,200 @@
+// Package analyzer provides an analysis.Analyzer which reports bases of
+// goxr.OpenBox(..) and goxr.OpenBoxBy(..) calls which do not exist and
+// literal paths which are opened from these boxes but do not exist in any of
+// their bases. It can be used with "go vet -vettool", gopls or any other
+// driver of golang.org/x/tools/go/analysis.
+package analyzer
+
+import (
+	"github.com/echocat/goxr/entry"
+	"github.com/echocat/goxr/manifest"
+	"github.com/echocat/goxr/usagescanner"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const goxrPackage = "github.com/echocat/goxr"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "goxrbox",
+	Doc:      "reports bases of goxr boxes and paths opened from them which do not exist",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// pathsOpeningFunctions are the functions of goxr which are opening a path of
+// the box given as first argument. The value is the index of the path argument.
+var pathsOpeningFunctions = map[string]int{
+	"ReadFile":     1,
+	"ReadString":   1,
+	"MustReadFile": 1,
+}
+
+type base struct {
+	prefix string
+	path   string
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	boxes := map[types.Object][]base{}
+	assignments := map[types.Object]int{}
+
+	ins.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					countAssignment(pass, assignments, ident)
+				}
+			}
+			if len(n.Rhs) == 1 && len(n.Lhs) > 0 {
+				if ident, ok := n.Lhs[0].(*ast.Ident); ok {
+					recordBox(pass, boxes, ident, n.Rhs[0])
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Values) > 0 {
+				for _, name := range n.Names {
+					countAssignment(pass, assignments, name)
+				}
+			}
+			if len(n.Values) == 1 && len(n.Names) > 0 {
+				recordBox(pass, boxes, n.Names[0], n.Values[0])
+			}
+		}
+	})
+	// A box variable which is assigned more than once could hold any box.
+	for obj := range boxes {
+		if assignments[obj] > 1 {
+			delete(boxes, obj)
+		}
+	}
+
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if c, ok := usagescanner.CallOf(pass.Fset, pass.TypesInfo, call); ok {
+			for _, b := range c.Bases {
+				if _, candidates, ok := resolveBase(pass, c, manifest.ParseBase(b).Path); !ok {
+					pass.Reportf(call.Pos(), "base %q of goxr.%s(..) does not exist: %s", b, c.Function, strings.Join(candidates, ", "))
+				}
+			}
+		} else if boxExpr, pathExpr, ok := openedPathOf(pass, call); ok {
+			checkOpenedPath(pass, boxes, boxExpr, pathExpr)
+		}
+	})
+
+	return nil, nil
+}
+
+func countAssignment(pass *analysis.Pass, assignments map[types.Object]int, ident *ast.Ident) {
+	if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
+		assignments[obj]++
+	}
+}
+
+// resolveBase returns the first existing location of the given base path of
+// the given call - the same way runtime.ResolveBase does while opening the box
+// (see runtime.BaseCandidates). If none exists all tried candidates are returned.
+func resolveBase(pass *analysis.Pass, c usagescanner.Call, path string) (string, []string, bool) {
+	c.Package = pass.Pkg.Path()
+	return c.ResolveBase(path)
+}
+
+// recordBox remembers the bases of the box which is assigned to the given
+// identifier if the value is a fully resolvable call of goxr.OpenBox(..) or
+// goxr.OpenBoxBy(..) and all of its bases exist.
+func recordBox(pass *analysis.Pass, boxes map[types.Object][]base, ident *ast.Ident, value ast.Expr) {
+	call, ok := ast.Unparen(value).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	c, ok := usagescanner.CallOf(pass.Fset, pass.TypesInfo, call)
+	if !ok || len(c.Unresolved) > 0 || len(c.Bases) == 0 {
+		return
+	}
+	obj := pass.TypesInfo.ObjectOf(ident)
+	if obj == nil {
+		return
+	}
+	var bases []base
+	for _, plain := range c.Bases {
+		b := manifest.ParseBase(plain)
+		path, _, ok := resolveBase(pass, c, b.Path)
+		if !ok {
+			return
+		}
+		bases = append(bases, base{prefix: entry.CleanPath(b.Prefix), path: path})
+	}
+	boxes[obj] = bases
+}
+
+// openedPathOf returns the box and path arguments if the given call is either
+// <box>.Open(..) or <box>.Info(..) of a goxr.Box or one of the
+// pathsOpeningFunctions.
+func openedPathOf(pass *analysis.Pass, call *ast.CallExpr) (boxExpr ast.Expr, pathExpr ast.Expr, ok bool) {
+	fn := typeutil.Callee(pass.TypesInfo, call)
+	f, isFunc := fn.(*types.Func)
+	if !isFunc || f.Pkg() == nil || f.Pkg().Path() != goxrPackage {
+		return nil, nil, false
+	}
+	sig := f.Type().(*types.Signature)
+	if sig.Recv() != nil {
+		sel, isSel := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !isSel || (f.Name() != "Open" && f.Name() != "Info") || len(call.Args) != 1 {
+			return nil, nil, false
+		}
+		return sel.X, call.Args[0], true
+	}
+	if i, known := pathsOpeningFunctions[f.Name()]; known && len(call.Args) > i {
+		return call.Args[0], call.Args[i], true
+	}
+	return nil, nil, false
+}
+
+func checkOpenedPath(pass *analysis.Pass, boxes map[types.Object][]base, boxExpr ast.Expr, pathExpr ast.Expr) {
+	ident, ok := ast.Unparen(boxExpr).(*ast.Ident)
+	if !ok {
+		return
+	}
+	bases, ok := boxes[pass.TypesInfo.ObjectOf(ident)]
+	if !ok {
+		return
+	}
+	tv, ok := pass.TypesInfo.Types[pathExpr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	path := entry.CleanPath(constant.StringVal(tv.Value))
+	for _, b := range bases {
+		if existsInBase(b, path) {
+			return
+		}
+	}
+	pass.Reportf(pathExpr.Pos(), "path %q does not exist in any base of the box", constant.StringVal(tv.Value))
+}
+
+func existsInBase(b base, path string) bool {
+	if b.prefix != "" {
+		if path == b.prefix {
+			return true
+		} else if !strings.HasPrefix(path, b.prefix+"/") {
+			return false
+		}
+		path = path[len(b.prefix)+1:]
+	}
+	return exists(filepath.Join(b.path, filepath.FromSlash(path)))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
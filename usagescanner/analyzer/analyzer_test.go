@@ -0,0 +1,22 @@
+package analyzer
+
+import (
+	"github.com/echocat/goxr/runtime"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestAnalyzer_boxRoot(t *testing.T) {
+	assert.NoError(t, os.Setenv(runtime.BoxRootEnv, filepath.Join(analysistest.TestData(), "src", "a")))
+	defer func() {
+		assert.NoError(t, os.Unsetenv(runtime.BoxRootEnv))
+	}()
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "b")
+}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/echocat/goxr/usagescanner/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
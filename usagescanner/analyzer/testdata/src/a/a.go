@@ -0,0 +1,50 @@
+package a
+
+import (
+	x "github.com/echocat/goxr"
+)
+
+const templatesBase = "tmpl=templates"
+
+var templates, _ = x.OpenBox(templatesBase)
+
+func static() {
+	box, _ := x.OpenBox("static")
+	_, _ = box.Open("index.html")
+	_, _ = box.Open("/css/main.css")
+	_, _ = box.Open("missing.html")    // want `path "missing.html" does not exist in any base of the box`
+	_, _ = box.Info("css/missing.css") // want `path "css/missing.css" does not exist in any base of the box`
+	_, _ = x.ReadFile(box, "index.html")
+	_, _ = x.ReadFile(box, "other.html") // want `path "other.html" does not exist in any base of the box`
+}
+
+func combined() {
+	box, _ := x.OpenBoxBy("a.box", "static", "tmpl=templates")
+	_, _ = box.Open("index.html")
+	_, _ = box.Open("tmpl/page.tmpl")
+	_, _ = box.Open("page.tmpl") // want `path "page.tmpl" does not exist in any base of the box`
+
+	_, _ = templates.Open("tmpl/page.tmpl")
+	_, _ = templates.Open("tmpl/missing.tmpl") // want `path "tmpl/missing.tmpl" does not exist in any base of the box`
+}
+
+func missingBase() {
+	box, _ := x.OpenBox("missing") // want `base "missing" of goxr.OpenBox\(..\) does not exist: .*missing`
+	_, _ = box.Open("not-reported.html")
+}
+
+func dynamic(name string) {
+	box, _ := x.OpenBox("static")
+	_, _ = box.Open(name)
+}
+
+func reassigned(other x.Box) {
+	box, _ := x.OpenBox("static")
+	_, _ = box.Open("index.html")
+	box = other
+	_, _ = box.Open("other.html")
+
+	templatesBox, _ := x.OpenBox("static")
+	templatesBox, _ = x.OpenBox("templates")
+	_, _ = templatesBox.Open("page.tmpl")
+}
@@ -0,0 +1,13 @@
+package b
+
+import (
+	"github.com/echocat/goxr"
+)
+
+func boxRoot() {
+	box, _ := goxr.OpenBox("static")
+	_, _ = box.Open("index.html")
+	_, _ = box.Open("missing.html") // want `path "missing.html" does not exist in any base of the box`
+
+	_, _ = goxr.OpenBox("b") // want `base "b" of goxr.OpenBox\(..\) does not exist: .*a.b`
+}
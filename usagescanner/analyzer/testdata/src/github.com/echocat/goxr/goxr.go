@@ -0,0 +1,21 @@
+// Package goxr is a minimal stub of github.com/echocat/goxr for the tests of the analyzer.
+package goxr
+
+import "os"
+
+type Box interface {
+	Open(name string) (*os.File, error)
+	Info(name string) (os.FileInfo, error)
+}
+
+func OpenBox(base ...string) (Box, error) {
+	return nil, nil
+}
+
+func OpenBoxBy(packedBoxCandidateFilename string, base ...string) (Box, error) {
+	return nil, nil
+}
+
+func ReadFile(box Box, name string) ([]byte, error) {
+	return nil, nil
+}
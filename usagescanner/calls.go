@@ -0,0 +1,101 @@
+package usagescanner
+
+import (
+	"github.com/echocat/goxr/runtime"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Call is a call of goxr.OpenBox or goxr.OpenBoxBy.
+type Call struct {
+	Position token.Position
+	// Package is the import path of the package which contains the call.
+	Package string
+	// Function is either OpenBox or OpenBoxBy.
+	Function string
+	// Bases contains the constant values of all base arguments.
+	Bases []string
+	// Unresolved contains the base arguments which are not constant.
+	Unresolved []Unresolved
+}
+
+// Unresolved is an argument of a Call which cannot be resolved to a constant.
+type Unresolved struct {
+	Position   token.Position
+	Expression string
+	Reason     string
+}
+
+// ResolvePath returns the location of the given path of a base relative to
+// the source file of the call.
+func (instance Call) ResolvePath(path string) string {
+	path = filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(instance.Position.Filename), path)
+}
+
+// ResolveBase returns the first existing location of the given path of a base
+// the same way runtime.ResolveBase does while opening the box (see
+// runtime.BaseCandidates) together with all candidates. If none exists it
+// returns false.
+func (instance Call) ResolveBase(path string) (string, []string, bool) {
+	if filepath.IsAbs(filepath.FromSlash(path)) {
+		path = filepath.Clean(filepath.FromSlash(path))
+		_, err := os.Stat(path)
+		return path, []string{path}, err == nil
+	}
+	candidates := runtime.BaseCandidates(path, runtime.Caller{
+		Filename: filepath.ToSlash(instance.Position.Filename),
+		Package:  instance.Package,
+	})
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, candidates, true
+		}
+	}
+	return "", candidates, false
+}
+
+type Calls []Call
+
+// Usages returns the sorted and distinct bases of all calls per file.
+func (instance Calls) Usages() Usages {
+	buffer := make(map[string]map[string]bool)
+	for _, call := range instance {
+		for _, base := range call.Bases {
+			if buffer[call.Position.Filename] == nil {
+				buffer[call.Position.Filename] = make(map[string]bool)
+			}
+			buffer[call.Position.Filename][base] = true
+		}
+	}
+
+	result := make(Usages, len(buffer))
+	for filename, bases := range buffer {
+		for base := range bases {
+			result[filename] = append(result[filename], base)
+		}
+		sort.Strings(result[filename])
+	}
+	return result
+}
+
+func (instance Calls) Len() int {
+	return len(instance)
+}
+
+func (instance Calls) Less(i, j int) bool {
+	a, b := instance[i].Position, instance[j].Position
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	return a.Offset < b.Offset
+}
+
+func (instance Calls) Swap(i, j int) {
+	instance[i], instance[j] = instance[j], instance[i]
+}
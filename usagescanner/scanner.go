@@ -1,33 +1,166 @@
 package usagescanner
 
 import (
+	"fmt"
+	"github.com/echocat/slf4g"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-func ScanForUsages(root string) (result Usages, err error) {
-	result = make(Usages)
-	if root, err = filepath.Abs(root); err != nil {
+const goxrPackage = "github.com/echocat/goxr"
+
+// Options influences which files are scanned.
+type Options struct {
+	// Tags are the build tags which are considered as satisfied.
+	Tags []string
+	// GOOS to consider instead of the current one.
+	GOOS string
+	// GOARCH to consider instead of the current one.
+	GOARCH string
+	// Tests includes also the _test.go files.
+	Tests bool
+}
+
+func ScanForUsages(root string) (Usages, error) {
+	if calls, err := Scan(root, Options{}); err != nil {
 		return Usages{}, err
+	} else {
+		return calls.Usages(), nil
 	}
-	if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+}
+
+// Scan loads all packages below root (including type information) and returns
+// every call of goxr.OpenBox and goxr.OpenBoxBy. Calls are resolved by their
+// real function objects, so aliased imports are found and functions with the
+// same name of other packages are ignored. Arguments are resolved to their
+// constant values, even if the constants are declared in other files or packages.
+func Scan(root string, options Options) (Calls, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := packages.Load(options.config(root), "./...")
+	if err != nil {
+		return nil, err
+	}
+	if err := errorsOf(pkgs); err != nil {
+		return nil, err
+	}
+
+	var result Calls
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(node ast.Node) bool {
+				if call, ok := node.(*ast.CallExpr); ok {
+					if c, ok := CallOf(pkg.Fset, pkg.TypesInfo, call); ok && !seen[c.Position.String()] {
+						seen[c.Position.String()] = true
+						c.Package = pkg.PkgPath
+						result = append(result, c)
+					}
+				}
+				return true
+			})
 		}
-		if info.IsDir() || filepath.Ext(path) != ".go" {
-			return nil
+	}
+	sort.Sort(result)
+	return result, nil
+}
+
+func (instance Options) config(root string) *packages.Config {
+	result := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+		Dir:   root,
+		Tests: instance.Tests,
+	}
+	if len(instance.Tags) > 0 {
+		result.BuildFlags = []string{"-tags=" + strings.Join(instance.Tags, ",")}
+	}
+	if instance.GOOS != "" || instance.GOARCH != "" {
+		result.Env = os.Environ()
+		if instance.GOOS != "" {
+			result.Env = append(result.Env, "GOOS="+instance.GOOS)
 		}
-		visitor := newVisitor(path)
-		if err := visitor.Run(); err != nil {
-			return err
+		if instance.GOARCH != "" {
+			result.Env = append(result.Env, "GOARCH="+instance.GOARCH)
 		}
-		if len(visitor.Boxes) > 0 {
-			result[path] = visitor.Boxes
+	}
+	return result
+}
+
+// errorsOf returns the errors of all packages which import goxr, because
+// calls of them could be missed. Errors of all other packages are only logged.
+func errorsOf(pkgs []*packages.Package) error {
+	var messages []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		_, importsGoxr := pkg.Imports[goxrPackage]
+		for _, err := range pkg.Errors {
+			if importsGoxr {
+				messages = append(messages, err.Error())
+			} else {
+				log.With("package", pkg.PkgPath).
+					Warnf("Ignoring error of package which does not use goxr: %v", err)
+			}
 		}
-		return nil
-	}); err != nil {
-		return Usages{}, err
+	})
+	if len(messages) > 0 {
+		return fmt.Errorf("cannot load packages:\n\t%s", strings.Join(messages, "\n\t"))
 	}
+	return nil
+}
 
-	return result, nil
+// IsOpenBoxFunction returns true if the given function is goxr.OpenBox or
+// goxr.OpenBoxBy.
+func IsOpenBoxFunction(fn *types.Func) bool {
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != goxrPackage {
+		return false
+	}
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() != nil {
+		return false
+	}
+	return fn.Name() == "OpenBox" || fn.Name() == "OpenBoxBy"
+}
+
+// CallOf returns the Call if the given expression is a call of goxr.OpenBox
+// or goxr.OpenBoxBy.
+func CallOf(fset *token.FileSet, info *types.Info, call *ast.CallExpr) (Call, bool) {
+	fn := typeutil.StaticCallee(info, call)
+	if !IsOpenBoxFunction(fn) {
+		return Call{}, false
+	}
+	result := Call{
+		Position: fset.Position(call.Pos()),
+		Function: fn.Name(),
+	}
+	args := call.Args
+	if fn.Name() == "OpenBoxBy" && len(args) > 0 {
+		args = args[1:]
+	}
+	for _, arg := range args {
+		if call.Ellipsis.IsValid() {
+			result.Unresolved = append(result.Unresolved, unresolvedOf(fset, arg, "variadic argument"))
+		} else if tv, ok := info.Types[arg]; !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			result.Unresolved = append(result.Unresolved, unresolvedOf(fset, arg, "not a constant string"))
+		} else {
+			result.Bases = append(result.Bases, constant.StringVal(tv.Value))
+		}
+	}
+	return result, true
+}
+
+func unresolvedOf(fset *token.FileSet, arg ast.Expr, reason string) Unresolved {
+	return Unresolved{
+		Position:   fset.Position(arg.Pos()),
+		Expression: types.ExprString(arg),
+		Reason:     reason,
+	}
 }
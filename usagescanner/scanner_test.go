@@ -2,6 +2,8 @@ package usagescanner
 
 import (
 	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -10,3 +12,98 @@ func TestScanForUsages(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []string{}, usages.Resolve())
 }
+
+func TestScan(t *testing.T) {
+	calls, err := Scan("testdata/example", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"static"},
+		{"templates"},
+		{"sub"},
+		{"assets", "moreAssets"},
+		nil,
+	}, basesOf(calls))
+	assert.Equal(t, "OpenBoxBy", calls[3].Function)
+	assert.Equal(t, "a.go", filepath.Base(calls[0].Position.Filename))
+	assert.Equal(t, 10, calls[0].Position.Line)
+
+	assert.Len(t, calls[4].Unresolved, 1)
+	assert.Equal(t, `os.Getenv("BASE")`, calls[4].Unresolved[0].Expression)
+	assert.Equal(t, 14, calls[4].Unresolved[0].Position.Line)
+}
+
+func TestScan_withBrokenPackages(t *testing.T) {
+	calls, err := Scan("testdata/broken", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"static"}}, basesOf(calls))
+
+	_, err = Scan("testdata/brokenUsage", Options{})
+	assert.Error(t, err)
+}
+
+func TestScan_withTags(t *testing.T) {
+	calls, err := Scan("testdata/example", Options{Tags: []string{"special"}})
+	assert.NoError(t, err)
+	assert.Contains(t, allBasesOf(calls), "special")
+	assert.NotContains(t, allBasesOf(calls), "windows")
+}
+
+func TestScan_withGOOS(t *testing.T) {
+	calls, err := Scan("testdata/example", Options{GOOS: "windows", GOARCH: "amd64"})
+	assert.NoError(t, err)
+	assert.Contains(t, allBasesOf(calls), "windows")
+	assert.NotContains(t, allBasesOf(calls), "special")
+}
+
+func TestCalls_Usages(t *testing.T) {
+	calls, err := Scan("testdata/example", Options{})
+	assert.NoError(t, err)
+	abs, err := filepath.Abs("testdata/example")
+	assert.NoError(t, err)
+
+	actual := calls.Usages().Resolve()
+	sort.Strings(actual)
+	assert.Equal(t, []string{
+		filepath.Join(abs, "assets"),
+		filepath.Join(abs, "moreAssets"),
+		filepath.Join(abs, "static"),
+		filepath.Join(abs, "sub"),
+		filepath.Join(abs, "templates"),
+	}, actual)
+}
+
+func TestCalls_Usages_distinct(t *testing.T) {
+	a, b := Call{Bases: []string{"static", "assets"}}, Call{Bases: []string{"assets", "templates"}}
+	a.Position.Filename, b.Position.Filename = "a.go", "a.go"
+	other := Call{Bases: []string{"static"}}
+	other.Position.Filename = "b.go"
+	unresolved := Call{}
+	unresolved.Position.Filename = "c.go"
+
+	assert.Equal(t, Usages{
+		"a.go": {"assets", "static", "templates"},
+		"b.go": {"static"},
+	}, Calls{a, b, other, unresolved}.Usages())
+}
+
+func TestCall_ResolvePath(t *testing.T) {
+	call := Call{}
+	call.Position.Filename = filepath.FromSlash("/foo/bar/main.go")
+	assert.Equal(t, filepath.FromSlash("/foo/bar/static"), call.ResolvePath("static"))
+	assert.Equal(t, filepath.FromSlash("/foo/static"), call.ResolvePath("../static"))
+	assert.Equal(t, filepath.FromSlash("/other"), call.ResolvePath("/other"))
+}
+
+func basesOf(calls Calls) (result [][]string) {
+	for _, call := range calls {
+		result = append(result, call.Bases)
+	}
+	return
+}
+
+func allBasesOf(calls Calls) (result []string) {
+	for _, call := range calls {
+		result = append(result, call.Bases...)
+	}
+	return
+}
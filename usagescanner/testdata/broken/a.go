@@ -0,0 +1,7 @@
+package broken
+
+import "github.com/echocat/goxr"
+
+func open() {
+	_, _ = goxr.OpenBox("static")
+}
@@ -0,0 +1,5 @@
+package other
+
+func broken() int {
+	return "not an int"
+}
@@ -0,0 +1,8 @@
+package brokenUsage
+
+import "github.com/echocat/goxr"
+
+func open() int {
+	_, _ = goxr.OpenBox("static")
+	return "not an int"
+}
@@ -0,0 +1,15 @@
+package example
+
+import (
+	x "github.com/echocat/goxr"
+	"github.com/echocat/goxr/usagescanner/testdata/example/sub"
+	"os"
+)
+
+func open() {
+	_, _ = x.OpenBox("static")
+	_, _ = x.OpenBox(templatesBase)
+	_, _ = x.OpenBox(sub.Base)
+	_, _ = x.OpenBoxBy("assets.box", "assets", "more"+"Assets")
+	_, _ = x.OpenBox(os.Getenv("BASE"))
+}
@@ -0,0 +1,12 @@
+package example
+
+const templatesBase = "templates"
+
+type fake struct{}
+
+func (fake) OpenBox(string) {}
+
+func openFake() {
+	var goxr fake
+	goxr.OpenBox("wrong")
+}
@@ -0,0 +1,7 @@
+package example
+
+import "github.com/echocat/goxr"
+
+func openWindows() {
+	_, _ = goxr.OpenBox("windows")
+}
@@ -0,0 +1,9 @@
+//go:build special
+
+package example
+
+import "github.com/echocat/goxr"
+
+func openSpecial() {
+	_, _ = goxr.OpenBox("special")
+}